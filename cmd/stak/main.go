@@ -9,6 +9,8 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
 	"stak/internal/config"
+	"stak/pkg/categorizer"
+	"stak/pkg/cli"
 	"stak/pkg/ui"
 )
 
@@ -21,6 +23,11 @@ var (
 )
 
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: stak [flags] [command]\n\nFlags:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\n%s", cli.Usage)
+	}
 	flag.Parse()
 
 	if *version {
@@ -34,6 +41,12 @@ func main() {
 		fmt.Printf("Error loading config: %v\n", err)
 		os.Exit(1)
 	}
+	// The shipped locale packs live with the categorizer, so they are
+	// checked here rather than by the config package
+	if _, err := categorizer.LocaleRules(cfg.Locales); err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Override data directory if provided via flag
 	if *dataDir != "" {
@@ -76,6 +89,26 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Subcommands run without the TUI
+	if flag.NArg() > 0 {
+		if !cli.IsCommand(flag.Arg(0)) {
+			fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", flag.Arg(0))
+			flag.Usage()
+			os.Exit(2)
+		}
+
+		app := cli.NewWithConfig(cfg)
+		if err := cfg.EnsureDataDir(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error initializing storage: %v\n", err)
+			os.Exit(1)
+		}
+		if err := app.Run(flag.Args()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	log.SetLevel(log.InfoLevel)
 	log.Info("Starting stak...", "dataDir", cfg.DataDir)
 
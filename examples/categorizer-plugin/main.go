@@ -0,0 +1,90 @@
+// Command categorizer-plugin is a reference categorizer for stak's
+// "command" categorizer. stak writes the entry being captured to stdin as
+// JSON and reads the answer from stdout:
+//
+//	{"type": "todo", "tags": ["ops"], "metadata": {"team": "ops"},
+//	 "confidence": 0.9, "reasons": ["mentions an incident"]}
+//
+// Only type is required. A non-zero exit, a timeout or output stak cannot
+// use makes it fall back to its built-in rules, showing the first line of
+// stderr in the entry's explanation.
+//
+// This plugin files anything about incidents or on-call as an ops todo and
+// everything else as a note. It also fails on purpose when the text asks it
+// to, so the failure handling can be tried out:
+//
+//	plugin:fail   exits with an error
+//	plugin:hang   never answers
+//	plugin:junk   prints something that is not JSON
+//
+// Build it and point the config at the binary:
+//
+//	go build -o ~/.stak/categorize ./examples/categorizer-plugin
+//
+//	categorizer: command
+//	categorizer_command:
+//	  path: ~/.stak/categorize
+//	  timeout: 2s
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// entry holds the fields of stak's entry JSON this plugin reads
+type entry struct {
+	ID      string   `json:"id"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+}
+
+type result struct {
+	Type       string            `json:"type"`
+	Tags       []string          `json:"tags,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Confidence float64           `json:"confidence,omitempty"`
+	Reasons    []string          `json:"reasons,omitempty"`
+}
+
+func main() {
+	var in entry
+	if err := json.NewDecoder(os.Stdin).Decode(&in); err != nil {
+		fmt.Fprintf(os.Stderr, "reading entry: %v\n", err)
+		os.Exit(1)
+	}
+
+	text := strings.ToLower(in.Content)
+	switch {
+	case strings.Contains(text, "plugin:fail"):
+		fmt.Fprintln(os.Stderr, "asked to fail")
+		os.Exit(3)
+	case strings.Contains(text, "plugin:hang"):
+		time.Sleep(time.Hour)
+	case strings.Contains(text, "plugin:junk"):
+		fmt.Println("not json")
+		return
+	}
+
+	out := result{Type: "note", Tags: []string{"note"}, Confidence: 0.6}
+	for _, word := range []string{"incident", "on-call", "outage", "pager"} {
+		if strings.Contains(text, word) {
+			out = result{
+				Type:       "todo",
+				Tags:       []string{"ops", "todo"},
+				Metadata:   map[string]string{"team": "ops"},
+				Confidence: 0.9,
+				Reasons:    []string{fmt.Sprintf("mentions %q", word)},
+			}
+			break
+		}
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, "writing result: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,150 @@
+package application
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"stak/internal/models"
+)
+
+var (
+	// !high, !medium, !med, !low and !none to clear
+	priorityTokenRegex = regexp.MustCompile(`(?i)(^|\s)!(high|medium|med|low|none)(\s|$)`)
+	// !h, !m, !l and !1, !2, !3, which are only read in todos
+	shortPriorityTokenRegex = regexp.MustCompile(`(?i)(^|\s)!(h|m|l|1|2|3)(\s|$)`)
+	// due:2025-10-01, due:2025-10-01T15:00, due:today, due:tomorrow, due:none
+	dueTokenRegex = regexp.MustCompile(`(?i)(^|\s)due:(\S+)`)
+)
+
+// todoAttributes holds the priority and due date written inline in captured
+// text. Set fields were given explicitly; clear fields asked for removal.
+type todoAttributes struct {
+	priority      models.Priority
+	clearPriority bool
+	dueAt         *time.Time
+	clearDue      bool
+}
+
+// extractTodoAttributes pulls !priority and due: tokens out of content.
+// Tokens that don't parse, and those in code or links, are left in the text
+// untouched. Short priorities are left for extractShortPriority.
+func extractTodoAttributes(content string, now time.Time) (string, todoAttributes) {
+	var attrs todoAttributes
+
+	content = replaceTokens(priorityTokenRegex, content, func(value string) bool {
+		if strings.EqualFold(value, "none") {
+			attrs.clearPriority = true
+			return true
+		}
+		attrs.priority, _ = models.ParsePriority(value)
+		return true
+	})
+
+	content = replaceTokens(dueTokenRegex, content, func(value string) bool {
+		if strings.EqualFold(value, "none") {
+			attrs.clearDue = true
+			return true
+		}
+		due, ok := parseDueValue(value, now)
+		if ok {
+			attrs.dueAt = &due
+		}
+		return ok
+	})
+
+	return content, attrs
+}
+
+// extractShortPriority pulls short priority tokens such as !1 and !h out of
+// a todo's content. They are too easily part of other text, as in
+// "x = !1", to be read in entries that are not todos already.
+func extractShortPriority(content string, attrs *todoAttributes) string {
+	return replaceTokens(shortPriorityTokenRegex, content, func(value string) bool {
+		attrs.priority, _ = models.ParsePriority(value)
+		return true
+	})
+}
+
+// replaceTokens removes each match of re whose value (the second group) is
+// accepted, joining the text either side with a single space so line breaks
+// elsewhere in multi-line entries survive. Matches in code or links are
+// skipped.
+func replaceTokens(re *regexp.Regexp, content string, accept func(value string) bool) string {
+	for offset := 0; offset < len(content); {
+		loc := re.FindStringSubmatchIndex(content[offset:])
+		if loc == nil {
+			break
+		}
+		// The token runs from after the leading space to the end of its value
+		start, end := offset+loc[3], offset+loc[5]
+		if models.InSpan(models.QuotedSpans(content), start, end) || !accept(content[offset+loc[4]:offset+loc[5]]) {
+			offset = end
+			continue
+		}
+
+		left := strings.TrimRight(content[:start], " \t")
+		right := strings.TrimLeft(content[end:], " \t")
+		switch {
+		case left == "" || strings.HasSuffix(left, "\n"):
+			content = left + right
+		case right == "" || strings.HasPrefix(right, "\n"):
+			content = left + right
+		default:
+			content = left + " " + right
+		}
+		offset = len(left)
+	}
+	return strings.TrimSpace(content)
+}
+
+// parseDueValue understands today, tomorrow and ISO dates with an optional time
+func parseDueValue(value string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(value) {
+	case "today":
+		return today, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	}
+
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02"} {
+		if due, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return due, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// applyTodoAttributes copies the extracted attributes onto entry
+func applyTodoAttributes(entry *models.Entry, attrs todoAttributes) {
+	if attrs.clearPriority {
+		entry.Priority = ""
+	}
+	if attrs.priority != "" {
+		entry.Priority = attrs.priority
+	}
+	if attrs.clearDue {
+		entry.DueAt = nil
+	}
+	if attrs.dueAt != nil {
+		entry.DueAt = attrs.dueAt
+	}
+}
+
+// promoteToTodo turns a plain note carrying a priority or due date into a
+// todo, since only todos are scheduled
+func promoteToTodo(entry *models.Entry) {
+	if entry.Type != models.TypeNote || (entry.Priority == "" && entry.DueAt == nil) {
+		return
+	}
+	entry.Type = models.TypeTodo
+	entry.TodoStatus = models.TodoPending
+	entry.Tags = []string{"todo", "task"}
+	entry.ApplyInlineTokens()
+	entry.SetCategorization(models.Categorization{
+		Type:       models.TypeTodo,
+		Confidence: 1,
+		Reasons:    []string{"has a priority or due date"},
+	})
+}
@@ -0,0 +1,100 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"stak/internal/models"
+)
+
+func TestExtractTodoAttributes(t *testing.T) {
+	now := time.Date(2025, 9, 10, 14, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		input    string
+		content  string
+		priority models.Priority
+		due      string
+		clearDue bool
+	}{
+		{
+			name:     "Priority and due date",
+			input:    "renew passport !high due:2025-10-01",
+			content:  "renew passport",
+			priority: models.PriorityHigh,
+			due:      "2025-10-01 00:00",
+		},
+		{
+			name:    "Due date with a time",
+			input:   "due:2025-09-12T15:30 submit report",
+			content: "submit report",
+			due:     "2025-09-12 15:30",
+		},
+		{
+			name:    "Relative due date, short priority left for todos",
+			input:   "call the bank !m due:tomorrow",
+			content: "call the bank !m",
+			due:     "2025-09-11 00:00",
+		},
+		{
+			name:     "Clearing the due date",
+			input:    "call the bank due:none",
+			content:  "call the bank",
+			clearDue: true,
+		},
+		{
+			name:     "Line breaks are kept",
+			input:    "fix the build !low\n  go test ./...",
+			content:  "fix the build\n  go test ./...",
+			priority: models.PriorityLow,
+		},
+		{
+			name:    "Tokens in code are left alone",
+			input:   "try `grep !high due:today` and ```\nx = !low\n```",
+			content: "try `grep !high due:today` and ```\nx = !low\n```",
+		},
+		{
+			name:     "Tokens in links are left alone",
+			input:    "read https://example.com/q?a= due:2025-10-01 !low",
+			content:  "read https://example.com/q?a=",
+			priority: models.PriorityLow,
+			due:      "2025-10-01 00:00",
+		},
+		{
+			name:    "Unparseable tokens stay in the text",
+			input:   "wow! due:someday",
+			content: "wow! due:someday",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, attrs := extractTodoAttributes(tt.input, now)
+			if content != tt.content {
+				t.Errorf("expected content %q, got %q", tt.content, content)
+			}
+			if attrs.priority != tt.priority {
+				t.Errorf("expected priority %q, got %q", tt.priority, attrs.priority)
+			}
+			due := ""
+			if attrs.dueAt != nil {
+				due = attrs.dueAt.Format("2006-01-02 15:04")
+			}
+			if due != tt.due {
+				t.Errorf("expected due %q, got %q", tt.due, due)
+			}
+			if attrs.clearDue != tt.clearDue {
+				t.Errorf("expected clearDue %v, got %v", tt.clearDue, attrs.clearDue)
+			}
+		})
+	}
+}
+
+func TestExtractShortPriority(t *testing.T) {
+	var attrs todoAttributes
+	content := extractShortPriority("call the bank !1 about `x = !2`", &attrs)
+	if content != "call the bank about `x = !2`" || attrs.priority != models.PriorityHigh {
+		t.Errorf("expected the short priority read outside code, got %q %q", content, attrs.priority)
+	}
+}
@@ -0,0 +1,177 @@
+package application
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"stak/internal/models"
+	"stak/internal/ports"
+)
+
+// LinkDuplicate is a stored entry already holding a link being captured
+type LinkDuplicate struct {
+	URL   string       `json:"url"`   // the captured link, normalized
+	Entry models.Entry `json:"entry"` // the earliest entry holding it
+}
+
+// FindDuplicateLinks returns, for each link in content that is already
+// stored, the entry that saved it first. Links match on their normalized
+// address, or on the canonical URL their page names once it is known.
+// Candidates come from the link index kept with the link cache, which is
+// built from every stored entry the first time it is needed.
+func (s *EntryService) FindDuplicateLinks(content string) ([]LinkDuplicate, error) {
+	urls := s.extractor.ExtractLinks(content)
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	var duplicates []LinkDuplicate
+	for _, url := range urls {
+		captured := s.capturedKeys(url)
+		ids, err := s.holders(captured)
+		if err != nil {
+			return nil, err
+		}
+
+		var earliest *models.Entry
+		for _, id := range ids {
+			entry, err := s.storage.LoadEntry(id)
+			if errors.Is(err, ports.ErrEntryNotFound) {
+				continue // Deleted or archived since it was indexed
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !s.holdsAny(*entry, captured) {
+				continue
+			}
+			if earliest == nil || entry.CreatedAt.Before(earliest.CreatedAt) {
+				earliest = entry
+			}
+		}
+		if earliest != nil {
+			duplicates = append(duplicates, LinkDuplicate{URL: url, Entry: *earliest})
+		}
+	}
+	return duplicates, nil
+}
+
+// holders returns the IDs of the entries indexed as holding one of keys
+func (s *EntryService) holders(keys []string) ([]string, error) {
+	var ids []string
+	for _, key := range keys {
+		held, ok := s.links.Holders(key)
+		if !ok {
+			if err := s.reindexLinks(); err != nil {
+				return nil, err
+			}
+			held, _ = s.links.Holders(key)
+		}
+		for _, id := range held {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// reindexLinks builds the link index from every stored entry
+func (s *EntryService) reindexLinks() error {
+	entries, err := s.storage.LoadAllEntries()
+	if err != nil {
+		return err
+	}
+
+	holders := make(map[string][]string)
+	for _, entry := range entries {
+		for _, key := range s.linkKeys(entry) {
+			holders[key] = append(holders[key], entry.ID)
+		}
+	}
+	return s.links.Reindex(holders)
+}
+
+// indexLinks records the links entry holds in the link index
+func (s *EntryService) indexLinks(entry models.Entry) {
+	s.links.Index(entry.ID, s.linkKeys(entry))
+}
+
+// MergeEntry adds a capture repeating links entryID already holds to that
+// entry, instead of saving a duplicate. The repeated links are dropped and
+// any other text is appended to the entry on a line of its own, picking up
+// its tags; a capture adding nothing new leaves the entry as it was.
+func (s *EntryService) MergeEntry(entryID, content string) (*models.Entry, error) {
+	entry, err := s.storage.LoadEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []string
+	for _, line := range strings.Split(content, "\n") {
+		var words []string
+		for _, word := range strings.Fields(line) {
+			if !s.repeatsLinks(*entry, word) {
+				words = append(words, word)
+			}
+		}
+		if len(words) > 0 {
+			kept = append(kept, strings.Join(words, " "))
+		}
+	}
+
+	extra := strings.Join(kept, "\n")
+	if extra == "" || strings.Contains(entry.Content, extra) {
+		return entry, nil
+	}
+	return s.EditEntry(entryID, entry.Content+"\n"+extra, false)
+}
+
+// repeatsLinks reports whether word is made up of links entry holds, such
+// as "(https://go.dev/blog?utm_source=feed)."
+func (s *EntryService) repeatsLinks(entry models.Entry, word string) bool {
+	urls := s.extractor.ExtractLinks(word)
+	for _, url := range urls {
+		if !s.holdsAny(entry, s.capturedKeys(url)) {
+			return false
+		}
+	}
+	return len(urls) > 0
+}
+
+// capturedKeys returns what a captured link is matched on: its address and
+// the canonical URL cached for its page
+func (s *EntryService) capturedKeys(url string) []string {
+	keys := []string{url}
+	if meta, ok := s.links.Lookup(url); ok && meta.CanonicalURL != "" {
+		keys = append(keys, s.extractor.NormalizeURL(meta.CanonicalURL))
+	}
+	return keys
+}
+
+// holdsAny reports whether one of entry's links has an address or
+// canonical URL among keys
+func (s *EntryService) holdsAny(entry models.Entry, keys []string) bool {
+	for _, held := range s.linkKeys(entry) {
+		if slices.Contains(keys, held) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkKeys returns what entry's links are matched on: their addresses and
+// the canonical URLs their pages name. Stored addresses are normalized
+// again, since links saved before normalization, or edited by hand, may not
+// be.
+func (s *EntryService) linkKeys(entry models.Entry) []string {
+	var keys []string
+	for _, link := range entry.Links {
+		keys = append(keys, s.extractor.NormalizeURL(link.URL))
+		if link.Metadata != nil && link.Metadata.CanonicalURL != "" {
+			keys = append(keys, s.extractor.NormalizeURL(link.Metadata.CanonicalURL))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,123 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"stak/internal/models"
+	"stak/internal/ports"
+)
+
+func TestFindDuplicateLinks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<title>Post</title><link rel="canonical" href="/articles/post">`))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local))
+	saved, err := s.CreateEntry("read "+srv.URL+"/post?utm_source=feed", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+	if len(saved.Links) != 1 || saved.Links[0].URL != srv.URL+"/post" {
+		t.Fatalf("expected the tracking parameter to be stripped, got %+v", saved.Links)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"Same link with other tracking parameters", "again " + srv.URL + "/post/?utm_medium=email&fbclid=abc", true},
+		{"Uppercase scheme", "HTTP://" + srv.Listener.Addr().String() + "/post", true},
+		{"The canonical URL the page names", srv.URL + "/articles/post", true},
+		{"Another page", srv.URL + "/other", false},
+		{"No links", "just a note", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duplicates, err := s.FindDuplicateLinks(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(duplicates) == 1 && duplicates[0].Entry.ID == saved.ID; got != tt.want {
+				t.Errorf("expected duplicate %v, got %+v", tt.want, duplicates)
+			}
+		})
+	}
+}
+
+func TestMergeEntry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<title>Post</title>`))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local))
+	saved, _ := s.CreateEntry("read "+srv.URL+"/post", nil)
+	s.Wait()
+
+	merged, err := s.MergeEntry(saved.ID, srv.URL+"/post?utm_campaign=weekly (worth rereading) #gardening")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+	if merged.Content != "read "+srv.URL+"/post\n(worth rereading) #gardening" {
+		t.Errorf("expected the new text appended without the repeated link, got %q", merged.Content)
+	}
+	if len(merged.Links) != 1 || !slices.Contains(merged.Tags, "gardening") {
+		t.Errorf("expected one link and the new tag, got %+v %v", merged.Links, merged.Tags)
+	}
+
+	again, err := s.MergeEntry(saved.ID, "("+srv.URL+"/post/).")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Content != merged.Content {
+		t.Errorf("expected a bare repeat to change nothing, got %q", again.Content)
+	}
+}
+
+// countingStorage counts the reads of every stored entry
+type countingStorage struct {
+	ports.StoragePort
+	loads int
+}
+
+func (c *countingStorage) LoadAllEntries() ([]models.Entry, error) {
+	c.loads++
+	return c.StoragePort.LoadAllEntries()
+}
+
+func TestFindDuplicateLinksUsesTheIndex(t *testing.T) {
+	s := newTestService(t, time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local))
+	counting := &countingStorage{StoragePort: s.storage}
+	s.storage = counting
+
+	first, _ := s.CreateEntry("read https://blog.invalid/first", nil)
+	s.Wait()
+	if duplicates, _ := s.FindDuplicateLinks("https://blog.invalid/first"); len(duplicates) != 1 {
+		t.Fatalf("expected the entry saved before the index to be found, got %+v", duplicates)
+	}
+
+	second, _ := s.CreateEntry("read https://blog.invalid/second", nil)
+	s.Wait()
+	duplicates, _ := s.FindDuplicateLinks("https://blog.invalid/second")
+	if len(duplicates) != 1 || duplicates[0].Entry.ID != second.ID {
+		t.Errorf("expected the newly indexed entry, got %+v", duplicates)
+	}
+	if counting.loads != 1 {
+		t.Errorf("expected every entry to be read once to build the index, got %d reads", counting.loads)
+	}
+
+	if err := s.DeleteEntry(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if duplicates, _ := s.FindDuplicateLinks("https://blog.invalid/first"); len(duplicates) != 0 {
+		t.Errorf("expected a deleted entry to be skipped, got %+v", duplicates)
+	}
+}
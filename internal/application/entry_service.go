@@ -1,7 +1,9 @@
 package application
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"stak/internal/models"
@@ -11,95 +13,268 @@ import (
 type EntryService struct {
 	storage     ports.StoragePort
 	categorizer ports.CategorizerPort
+	entities    ports.EntityExtractorPort
 	extractor   ports.ExtractorPort
+	links       ports.LinkCachePort
 	searcher    ports.SearchPort
+	dates       ports.DateParserPort
+	now         func() time.Time
+	background  sync.WaitGroup // in-flight link metadata lookups
+	fetchSlots  chan struct{}  // bounds how many links are fetched at once
+}
+
+// linkFetchWorkers is how many link pages are fetched at the same time
+const linkFetchWorkers = 4
+
+// EntryFilter narrows ListEntries. Zero values match everything; Since and
+// Until are inclusive days.
+type EntryFilter struct {
+	Type   models.EntryType
+	Status models.TodoStatus
+	Since  time.Time
+	Until  time.Time
 }
 
 func NewEntryService(
 	storage ports.StoragePort,
 	categorizer ports.CategorizerPort,
+	entities ports.EntityExtractorPort,
 	extractor ports.ExtractorPort,
+	links ports.LinkCachePort,
 	searcher ports.SearchPort,
+	dates ports.DateParserPort,
 ) *EntryService {
 	return &EntryService{
 		storage:     storage,
 		categorizer: categorizer,
+		entities:    entities,
 		extractor:   extractor,
+		links:       links,
 		searcher:    searcher,
+		dates:       dates,
+		now:         time.Now,
+		fetchSlots:  make(chan struct{}, linkFetchWorkers),
 	}
 }
 
 func (s *EntryService) CreateEntry(content string, forceType *models.EntryType) (*models.Entry, error) {
-	entry := models.NewEntry(content)
+	entry := models.NewEntryAt(content, s.now())
+
+	s.prepareEntry(entry, forceType)
 
+	if err := s.storage.SaveEntry(entry); err != nil {
+		return entry, err
+	}
+
+	s.indexLinks(*entry)
+	s.fetchLinkMetadata(entry, nil)
 	if forceType != nil {
-		entry.Type = *forceType
-		if *forceType == models.TypeTodo {
-			entry.TodoStatus = models.TodoPending
-			entry.Tags = []string{"todo", "task"}
+		if err := s.learn(*entry); err != nil {
+			return entry, err
 		}
+	}
+	return entry, nil
+}
+
+// categorise applies forceType, or the categorizer when no type is forced,
+// and records why the entry got its type. Forced todos get the standard
+// todo tags; other forced types still pick up the categorizer's tags.
+func (s *EntryService) categorise(entry *models.Entry, forceType *models.EntryType) {
+	if forceType == nil {
+		entry.SetCategorization(s.categorizer.CategoriseEntry(entry))
+		return
+	}
+
+	byHand := models.Categorization{Type: *forceType, Confidence: 1, Reasons: []string{"chosen by hand"}}
+	if *forceType == models.TypeTodo {
+		entry.Type = models.TypeTodo
+		entry.TodoStatus = models.TodoPending
+		entry.Tags = []string{"todo", "task"}
+		entry.ApplyInlineTokens()
 	} else {
 		s.categorizer.CategoriseEntry(entry)
+		entry.Type = *forceType
+		entry.TodoStatus = ""
 	}
+	entry.SetCategorization(byHand)
+}
 
-	// Handle link extraction asynchronously if needed
-	if entry.Type == models.TypeLink && entry.URL != "" {
-		go func() {
-			if title, err := s.extractor.GetURLTitle(entry.URL); err == nil {
-				entry.URLTitle = title
-				s.storage.SaveEntry(entry) // Save updated entry with title
-			}
-		}()
+// prepareEntry strips priority and due date tokens from the captured text,
+// categorises what remains and applies the tokens, reading short priorities
+// such as !1 only once the entry is a todo. A date expression such as "next
+// friday" becomes a todo's due date and is removed from the content. Any
+// other entry moves to that day only when the expression is definite, so
+// "met Bob on monday" stays as written on the day it was captured. An
+// unforced note that was given a
+// priority or due date becomes a todo, and a forced type is recorded as
+// chosen by hand. Entities and links are taken from the final text.
+func (s *EntryService) prepareEntry(entry *models.Entry, forceType *models.EntryType) {
+	now := s.now()
+	content, attrs := extractTodoAttributes(entry.Content, now)
+	entry.Content = content
+
+	// Categorise before stripping the date, since words like "tomorrow"
+	// help tell todos and meetings apart
+	s.categorise(entry, forceType)
+	if entry.Type == models.TypeTodo {
+		entry.Content = extractShortPriority(entry.Content, &attrs)
 	}
 
-	return entry, s.storage.SaveEntry(entry)
-}
+	match, ok := s.dates.ParseDate(entry.Content, now)
+	if ok && (entry.Type == models.TypeTodo || match.Definite) {
+		if match.Text != "" {
+			entry.Content = match.Text
+		}
+		if entry.Type == models.TypeTodo {
+			if attrs.dueAt == nil && !attrs.clearDue {
+				attrs.dueAt = &match.Time
+			}
+		} else {
+			scheduleOn(entry, match)
+		}
+	}
 
-func (s *EntryService) CreateTomorrowEntry(content string) error {
-	entry := models.NewEntry(content)
-	s.categorizer.CategoriseEntry(entry)
-	return s.storage.SaveEntryForTomorrow(entry)
+	applyTodoAttributes(entry, attrs)
+	if forceType == nil {
+		promoteToTodo(entry)
+	} else {
+		entry.MarkCategorizedByHand()
+	}
+	entry.Entities = s.entities.ExtractEntities(entry.Content)
+	entry.SetLinks(s.extractor.ExtractLinks(entry.Content))
 }
 
 func (s *EntryService) CreateEntryForDate(content string, date time.Time, forceType *models.EntryType) (*models.Entry, error) {
-	entry := models.NewEntry(content)
+	entry := models.NewEntryAt(content, s.now())
 
 	// Override the created date with the specified date
 	entry.CreatedAt = date
 	entry.UpdatedAt = date
 
+	s.prepareEntry(entry, forceType)
+
+	if err := s.storage.SaveEntry(entry); err != nil {
+		return entry, err
+	}
+
+	s.indexLinks(*entry)
+	s.fetchLinkMetadata(entry, nil)
 	if forceType != nil {
-		entry.Type = *forceType
-		if *forceType == models.TypeTodo {
-			entry.TodoStatus = models.TodoPending
-			entry.Tags = []string{"todo", "task"}
+		if err := s.learn(*entry); err != nil {
+			return entry, err
 		}
-	} else {
-		s.categorizer.CategoriseEntry(entry)
 	}
+	return entry, nil
+}
 
-	// Handle link extraction asynchronously if needed
-	if entry.Type == models.TypeLink && entry.URL != "" {
-		go func() {
-			if title, err := s.extractor.GetURLTitle(entry.URL); err == nil {
-				entry.URLTitle = title
-				s.storage.SaveEntry(entry) // Save updated entry with title
+// scheduleOn files entry under the matched day, at the matched time or else
+// at its capture time of day
+func scheduleOn(entry *models.Entry, match ports.DateMatch) {
+	at := match.Time
+	if !match.HasTime {
+		hour, minute, second := entry.CreatedAt.Clock()
+		at = time.Date(at.Year(), at.Month(), at.Day(), hour, minute, second, 0, at.Location())
+	}
+	entry.CreatedAt = at
+}
+
+// fetchLinkMetadata looks up the page title, description and other
+// metadata of each of the entry's links not yet described, in the
+// background. A non-nil round also tracks the lookups.
+func (s *EntryService) fetchLinkMetadata(entry *models.Entry, round *sync.WaitGroup) {
+	for _, link := range entry.Links {
+		if link.Metadata == nil {
+			s.enrichLink(entry.ID, link.URL, round)
+		}
+	}
+}
+
+// enrichLink fills in the stored entry's link to url from the cache,
+// or by fetching the page, in the background. A failed fetch is queued for
+// RetryLinks. The stored entry is updated in place rather than re-saving a
+// copy, so late metadata never overwrites edits made in the meantime.
+// Callers that wait for a batch of lookups from another goroutine pass
+// their own round, since only the CLI may wait on every lookup.
+func (s *EntryService) enrichLink(id, url string, round *sync.WaitGroup) {
+	s.background.Add(1)
+	if round != nil {
+		round.Add(1)
+	}
+	go func() {
+		defer s.background.Done()
+		if round != nil {
+			defer round.Done()
+		}
+		meta, err := s.lookupLink(url)
+		if err != nil {
+			s.links.Enqueue(id, url, err)
+			return
+		}
+		s.links.Dequeue(id, url)
+		var updated models.Entry
+		err = s.storage.UpdateEntry(id, func(stored *models.Entry) {
+			for i := range stored.Links {
+				if stored.Links[i].URL == url {
+					stored.Links[i].Title = meta.Title
+					stored.Links[i].Metadata = &meta
+				}
 			}
-		}()
+			updated = *stored
+		})
+		// The page may name a canonical URL to index the entry under
+		if err == nil && meta.CanonicalURL != "" {
+			s.indexLinks(updated)
+		}
+	}()
+}
+
+// lookupLink returns url's cached metadata, or fetches and caches it once
+// one of the fetch slots is free
+func (s *EntryService) lookupLink(url string) (models.LinkMetadata, error) {
+	if meta, ok := s.links.Lookup(url); ok {
+		return meta, nil
+	}
+
+	s.fetchSlots <- struct{}{}
+	defer func() { <-s.fetchSlots }()
+
+	meta, err := s.extractor.GetURLMetadata(url)
+	if err != nil {
+		return models.LinkMetadata{}, err
 	}
+	s.links.Store(url, meta)
+	return meta, nil
+}
 
-	return entry, s.storage.SaveEntry(entry)
+// RetryLinks fetches again the links whose metadata could not be fetched
+// before and that are due another attempt, and waits for them to finish.
+// It returns how many were retried.
+func (s *EntryService) RetryLinks() int {
+	var round sync.WaitGroup
+	due := s.links.Due()
+	for _, retry := range due {
+		s.enrichLink(retry.EntryID, retry.URL, &round)
+	}
+	round.Wait()
+	return len(due)
 }
 
+// Wait blocks until background link lookups finish, so short-lived callers
+// like the CLI do not exit before titles are saved
+func (s *EntryService) Wait() {
+	s.background.Wait()
+}
+
+// ToggleTodoStatus completes a pending todo and reopens a completed or
+// cancelled one
 func (s *EntryService) ToggleTodoStatus(entryID string, entries []models.Entry) (*models.Entry, error) {
 	for i := range entries {
 		if entries[i].ID == entryID && entries[i].Type == models.TypeTodo {
 			if entries[i].TodoStatus == models.TodoPending {
-				entries[i].TodoStatus = models.TodoCompleted
+				entries[i].SetTodoStatus(models.TodoCompleted, s.now())
 			} else {
-				entries[i].TodoStatus = models.TodoPending
+				entries[i].SetTodoStatus(models.TodoPending, s.now())
 			}
-			entries[i].UpdatedAt = time.Now()
 
 			err := s.storage.SaveEntry(&entries[i])
 			return &entries[i], err
@@ -108,10 +283,149 @@ func (s *EntryService) ToggleTodoStatus(entryID string, entries []models.Entry)
 	return nil, nil
 }
 
+// EditEntry replaces an entry's content, keeping its ID and creation time.
+// With recategorize set the entry is run through the categorizer again; a
+// todo keeps its status if it is still a todo. Links are re-extracted,
+// keeping what was fetched for those still in the text. Priority and due
+// date tokens in content update those fields, and are otherwise left as
+// they were.
+func (s *EntryService) EditEntry(entryID, content string, recategorize bool) (*models.Entry, error) {
+	content, attrs := extractTodoAttributes(content, s.now())
+
+	var edited models.Entry
+	err := s.storage.UpdateEntry(entryID, func(entry *models.Entry) {
+		previousStatus := entry.TodoStatus
+
+		entry.Content = content
+		entry.UpdatedAt = s.now()
+
+		if recategorize {
+			var forceType *models.EntryType
+			if entry.CategorizedByHand() {
+				chosen := entry.Type
+				forceType = &chosen
+			}
+			s.recategorise(entry, forceType, previousStatus)
+		}
+		if entry.Type == models.TypeTodo {
+			entry.Content = extractShortPriority(entry.Content, &attrs)
+		}
+
+		// Pick up #tags, @people and +projects written into the new text,
+		// and the entities and links in it
+		entry.ApplyInlineTokens()
+		entry.Entities = s.entities.ExtractEntities(entry.Content)
+		entry.SetLinks(s.extractor.ExtractLinks(entry.Content))
+
+		applyTodoAttributes(entry, attrs)
+		if recategorize {
+			promoteToTodo(entry)
+		}
+
+		edited = *entry
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.indexLinks(edited)
+	s.fetchLinkMetadata(&edited, nil)
+	return &edited, nil
+}
+
+// SetEntryType changes an entry's type to one the user picked, re-deriving
+// its tags. The entry is marked as categorized by hand so later
+// edits keep the type, and a learning categorizer is told about the choice.
+func (s *EntryService) SetEntryType(entryID string, entryType models.EntryType) (*models.Entry, error) {
+	var changed models.Entry
+	err := s.storage.UpdateEntry(entryID, func(entry *models.Entry) {
+		s.recategorise(entry, &entryType, entry.TodoStatus)
+		entry.MarkCategorizedByHand()
+		entry.UpdatedAt = s.now()
+		changed = *entry
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.learn(changed); err != nil {
+		return &changed, err
+	}
+	return &changed, nil
+}
+
+// recategorise clears what categorization derived and runs it again, as
+// forceType when set. A todo keeps its previous status.
+func (s *EntryService) recategorise(entry *models.Entry, forceType *models.EntryType, previousStatus models.TodoStatus) {
+	entry.Type = ""
+	entry.Tags = []string{}
+	entry.TodoStatus = ""
+	s.categorise(entry, forceType)
+
+	if entry.Type == models.TypeTodo && previousStatus != "" {
+		entry.TodoStatus = previousStatus
+	}
+}
+
+// learn passes a type the user chose to the categorizer, if it learns
+func (s *EntryService) learn(entry models.Entry) error {
+	learner, ok := s.categorizer.(ports.CategoryLearnerPort)
+	if !ok {
+		return nil
+	}
+	if err := learner.Learn(entry); err != nil {
+		return fmt.Errorf("failed to save the learned model: %w", err)
+	}
+	return nil
+}
+
+// TrainCategorizer brings a learning categorizer up to date with every
+// stored entry. It reads the whole store, so the TUI runs it in the
+// background at startup and the CLI uses the model as last saved.
+func (s *EntryService) TrainCategorizer() error {
+	learner, ok := s.categorizer.(ports.CategoryLearnerPort)
+	if !ok {
+		return nil
+	}
+	entries, err := s.storage.LoadAllEntries()
+	if err != nil {
+		return err
+	}
+	if err := learner.Train(entries); err != nil {
+		return fmt.Errorf("failed to save the learned model: %w", err)
+	}
+	return nil
+}
+
 func (s *EntryService) LoadTodayEntries() ([]models.Entry, error) {
 	return s.storage.LoadTodayEntries()
 }
 
+func (s *EntryService) LoadEntriesForDate(date time.Time) ([]models.Entry, error) {
+	return s.storage.LoadEntriesForDate(date)
+}
+
+// LoadEntriesBetween loads entries from the day files between start and end, inclusive
+func (s *EntryService) LoadEntriesBetween(start, end time.Time) ([]models.Entry, error) {
+	return s.storage.LoadEntriesBetween(start, end)
+}
+
+// LoadTodosBetween loads only the todos captured between start and end, inclusive
+func (s *EntryService) LoadTodosBetween(start, end time.Time) ([]models.Entry, error) {
+	entries, err := s.storage.LoadEntriesBetween(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var todos []models.Entry
+	for _, entry := range entries {
+		if entry.Type == models.TypeTodo {
+			todos = append(todos, entry)
+		}
+	}
+	return todos, nil
+}
+
 func (s *EntryService) LoadFilteredEntries(entryType models.EntryType) ([]models.Entry, error) {
 	return s.storage.LoadFilteredEntries(entryType)
 }
@@ -128,3 +442,99 @@ func (s *EntryService) SearchEntries(query string, linksOnly bool) ([]models.Ent
 
 	return s.storage.SearchEntries(normalizedQuery, linksOnly)
 }
+
+// DeleteEntry moves an entry to the trash, from where it can be restored
+func (s *EntryService) DeleteEntry(entryID string) error {
+	return s.storage.DeleteEntry(entryID)
+}
+
+// ArchiveEntry hides an entry from the day views without deleting it
+func (s *EntryService) ArchiveEntry(entryID string) error {
+	return s.storage.ArchiveEntry(entryID)
+}
+
+// RestoreEntry returns a deleted or archived entry to its day file
+func (s *EntryService) RestoreEntry(entryID string) error {
+	return s.storage.RestoreEntry(entryID)
+}
+
+// LoadRemovedEntries loads the trashed entries followed by the archived ones
+func (s *EntryService) LoadRemovedEntries() ([]models.Entry, error) {
+	trashed, err := s.storage.LoadTrashedEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	archived, err := s.storage.LoadArchivedEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(trashed, archived...), nil
+}
+
+// BodyConflicts returns the day files whose hand edited body could not be
+// merged with their front matter, as found by the loads so far
+func (s *EntryService) BodyConflicts() []error {
+	return s.storage.Conflicts()
+}
+
+// ResolveBodyConflicts keeps the front matter of every conflicting day
+// file, returning the .bak copies holding the hand edits
+func (s *EntryService) ResolveBodyConflicts() ([]string, error) {
+	return s.storage.ResolveConflicts()
+}
+
+// GetEntry loads a single stored entry by ID
+func (s *EntryService) GetEntry(entryID string) (*models.Entry, error) {
+	return s.storage.LoadEntry(entryID)
+}
+
+// SetTodoStatus changes the status of a stored todo. Other entries are
+// refused without touching their day file.
+func (s *EntryService) SetTodoStatus(entryID string, status models.TodoStatus) (*models.Entry, error) {
+	entry, err := s.storage.LoadEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Type != models.TypeTodo {
+		return nil, fmt.Errorf("entry %s is a %s, not a todo", entryID, entry.Type)
+	}
+
+	var updated models.Entry
+	err = s.storage.UpdateEntry(entryID, func(entry *models.Entry) {
+		entry.SetTodoStatus(status, s.now())
+		updated = *entry
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// ListEntries loads the entries matching filter. Only the day files inside
+// the Since/Until range are read when either bound is set.
+func (s *EntryService) ListEntries(filter EntryFilter) ([]models.Entry, error) {
+	var entries []models.Entry
+	var err error
+	if filter.Since.IsZero() && filter.Until.IsZero() {
+		entries, err = s.storage.LoadAllEntries()
+	} else {
+		entries, err = s.storage.LoadEntriesBetween(filter.Since, filter.Until)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []models.Entry
+	for _, entry := range entries {
+		if filter.Type != "" && entry.Type != filter.Type {
+			continue
+		}
+		if filter.Status != "" && entry.TodoStatus != filter.Status {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	return matched, nil
+}
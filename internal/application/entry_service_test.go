@@ -0,0 +1,271 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"stak/internal/config"
+	"stak/internal/models"
+	"stak/internal/ports"
+	"stak/pkg/categorizer"
+	"stak/pkg/dateparse"
+	"stak/pkg/entities"
+	"stak/pkg/extractor"
+	"stak/pkg/linkcache"
+	"stak/pkg/search"
+	"stak/pkg/storage"
+)
+
+func newTestService(t *testing.T, now time.Time) *EntryService {
+	t.Helper()
+
+	cfg := config.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	s := NewEntryService(storage.New(cfg), categorizer.New(), entities.New(), extractor.NewLinkExtractor(),
+		linkcache.New(cfg.DataDir, 0), search.NewFuzzySearcher(), dateparse.New())
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestCaptureDateExpressions(t *testing.T) {
+	// Wednesday
+	now := time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local)
+	s := newTestService(t, now)
+
+	todo, err := s.CreateEntry("need to buy milk next friday", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.Type != models.TypeTodo || todo.Content != "need to buy milk" {
+		t.Errorf("expected a todo without the date phrase, got %s %q", todo.Type, todo.Content)
+	}
+	if todo.FormatDue() != "2025-09-12" {
+		t.Errorf("expected the todo due on friday, got %q", todo.FormatDue())
+	}
+
+	explicit, _ := s.CreateEntry("need to file taxes tomorrow due:2025-09-30", nil)
+	if explicit.FormatDue() != "2025-09-30" {
+		t.Errorf("expected an explicit due: token to win, got %q", explicit.FormatDue())
+	}
+	if !explicit.CreatedAt.Equal(now) {
+		t.Errorf("expected the entry captured at the service's clock, got %v", explicit.CreatedAt)
+	}
+
+	link, _ := s.CreateEntry("https://blog.invalid/posts/today", nil)
+	s.Wait()
+	if link.Content != "https://blog.invalid/posts/today" || !link.CreatedAt.Equal(now) {
+		t.Errorf("expected a date word in a link to be left alone, got %q at %v", link.Content, link.CreatedAt)
+	}
+
+	meeting, err := s.CreateEntry("team standup monday 3pm", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meeting.Type != models.TypeMeeting || meeting.Content != "team standup" {
+		t.Errorf("expected a meeting without the date phrase, got %s %q", meeting.Type, meeting.Content)
+	}
+
+	recalled, err := s.CreateEntry("met Bob on monday", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recalled.Content != "met Bob on monday" || !recalled.CreatedAt.Equal(now) {
+		t.Errorf("expected a note looking back to stay as written today, got %q at %v", recalled.Content, recalled.CreatedAt)
+	}
+
+	monday := time.Date(2025, 9, 15, 0, 0, 0, 0, time.Local)
+	entries, err := s.LoadEntriesForDate(monday)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected the meeting filed under monday, got %d entries (err %v)", len(entries), err)
+	}
+	if got := entries[0].CreatedAt.Format("2006-01-02 15:04"); got != "2025-09-15 15:00" {
+		t.Errorf("expected the meeting at monday 15:00, got %s", got)
+	}
+}
+
+func TestCaptureShortPriorities(t *testing.T) {
+	now := time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local)
+	s := newTestService(t, now)
+
+	note, err := s.CreateEntry("x = !1 and y", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.Type != models.TypeNote || note.Content != "x = !1 and y" || note.Priority != "" {
+		t.Errorf("expected a note left as written, got %s %q %q", note.Type, note.Content, note.Priority)
+	}
+
+	todo, err := s.CreateEntry("need to call the bank !1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.Type != models.TypeTodo || todo.Content != "need to call the bank" || todo.Priority != models.PriorityHigh {
+		t.Errorf("expected a high priority todo, got %s %q %q", todo.Type, todo.Content, todo.Priority)
+	}
+}
+
+func TestSetEntryType(t *testing.T) {
+	now := time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local)
+	s := newTestService(t, now)
+
+	entry, err := s.CreateEntry("groceries: milk and eggs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Type != models.TypeNote {
+		t.Fatalf("expected the rules to file a note, got %s", entry.Type)
+	}
+	if c, ok := entry.Categorization(); !ok || c.Reasons[0] != "no rule matched" {
+		t.Errorf("expected the categorization to be recorded, got %+v", c)
+	}
+
+	changed, err := s.SetEntryType(entry.ID, models.TypeTodo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Type != models.TypeTodo || changed.TodoStatus != models.TodoPending || !changed.CategorizedByHand() {
+		t.Errorf("expected a pending todo chosen by hand, got %s %q %v", changed.Type, changed.TodoStatus, changed.Metadata)
+	}
+	if c, _ := changed.Categorization(); c.Confidence != 1 || c.Reasons[0] != "chosen by hand" {
+		t.Errorf("expected the explanation to say the type was chosen by hand, got %+v", c)
+	}
+
+	// Recategorizing an edit keeps the type the user chose
+	edited, err := s.EditEntry(entry.ID, "groceries: milk, eggs and bread", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.Type != models.TypeTodo || edited.TodoStatus != models.TodoPending {
+		t.Errorf("expected the edit to stay a pending todo, got %s %q", edited.Type, edited.TodoStatus)
+	}
+}
+
+func TestCaptureEntities(t *testing.T) {
+	s := newTestService(t, time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local))
+
+	entry, err := s.CreateEntry("look into PROJ-1234 with sam@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Entities) != 2 || entry.Entities[0].Value != "PROJ-1234" || entry.Entities[1].Kind != "email" {
+		t.Errorf("expected a ticket and an email, got %+v", entry.Entities)
+	}
+
+	edited, err := s.EditEntry(entry.ID, "look into golang/go#42", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edited.Entities) != 1 || edited.EntityURL() != "https://github.com/golang/go/issues/42" {
+		t.Errorf("expected the edit to replace the entities, got %+v", edited.Entities)
+	}
+}
+
+func TestCaptureLinkMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/other" {
+			w.Write([]byte(`<title>Other post</title>`))
+			return
+		}
+		w.Write([]byte(`<title>Post</title><meta property="og:description" content="A post about gardens.">`))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, time.Now())
+	link, err := s.CreateEntry("read ("+srv.URL+"/post) and "+srv.URL+"/other.", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+
+	stored, _ := s.GetEntry(link.ID)
+	if len(stored.Links) != 2 {
+		t.Fatalf("expected both links, got %+v", stored.Links)
+	}
+	post, other := stored.Links[0], stored.Links[1]
+	if post.URL != srv.URL+"/post" || post.Title != "Post" || post.Metadata == nil || post.Metadata.Description != "A post about gardens." {
+		t.Errorf("expected the first page's metadata to be stored, got %+v", post)
+	}
+	if other.URL != srv.URL+"/other" || other.Title != "Other post" {
+		t.Errorf("expected the second page's title to be stored, got %+v", other)
+	}
+
+	edited, err := s.EditEntry(link.ID, "reread "+srv.URL+"/post", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edited.Links) != 1 || edited.Links[0].Metadata == nil || edited.Links[0].Metadata.Description != "A post about gardens." {
+		t.Errorf("expected an edit keeping the URL to keep the metadata, got %+v", edited.Links)
+	}
+}
+
+// linkTitle returns the title of the entry's first link, or ""
+func linkTitle(entry *models.Entry) string {
+	if len(entry.Links) == 0 {
+		return ""
+	}
+	return entry.Links[0].Title
+}
+
+// instantRetries is a link cache whose failed fetches are due at once
+type instantRetries struct {
+	ports.LinkCachePort
+	queued []ports.LinkRetry
+}
+
+func (c *instantRetries) Enqueue(entryID, url string, err error) error {
+	c.queued = append(c.queued, ports.LinkRetry{EntryID: entryID, URL: url, LastError: err.Error()})
+	return nil
+}
+
+func (c *instantRetries) Due() []ports.LinkRetry {
+	due := c.queued
+	c.queued = nil
+	return due
+}
+
+func (c *instantRetries) Dequeue(entryID, url string) error {
+	return nil
+}
+
+func TestLinkCacheAndRetries(t *testing.T) {
+	var requests atomic.Int32
+	online := atomic.Bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !online.Load() {
+			http.Error(w, "offline", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`<title>Back online</title>`))
+	}))
+	defer srv.Close()
+
+	s := newTestService(t, time.Now())
+	links := &instantRetries{LinkCachePort: s.links}
+	s.links = links
+
+	first, _ := s.CreateEntry("read "+srv.URL+"/post", nil)
+	s.Wait()
+	if stored, _ := s.GetEntry(first.ID); linkTitle(stored) != "" || len(links.queued) != 1 {
+		t.Fatalf("expected the failed fetch to be queued, got title %q and %d queued", linkTitle(stored), len(links.queued))
+	}
+
+	online.Store(true)
+	if retried := s.RetryLinks(); retried != 1 {
+		t.Errorf("expected 1 retry, got %d", retried)
+	}
+	if stored, _ := s.GetEntry(first.ID); linkTitle(stored) != "Back online" {
+		t.Errorf("expected the retry to fill in the title, got %q", linkTitle(stored))
+	}
+
+	// Another entry for the same page is served from the cache
+	second, _ := s.CreateEntry("also "+srv.URL+"/post", nil)
+	s.Wait()
+	if stored, _ := s.GetEntry(second.ID); linkTitle(stored) != "Back online" || requests.Load() != 2 {
+		t.Errorf("expected the cached title without a fetch, got %q after %d requests", linkTitle(stored), requests.Load())
+	}
+}
@@ -0,0 +1,138 @@
+package application
+
+import (
+	"sync"
+
+	"stak/internal/models"
+)
+
+// linkCheckWorkers is how many links CheckLinks requests at the same time
+// when no limit is given
+const linkCheckWorkers = 8
+
+// LinkCheckResult is what checking one of an entry's links found
+type LinkCheckResult struct {
+	Entry models.Entry     `json:"entry"` // as it was before the check
+	URL   string           `json:"url"`
+	Check models.LinkCheck `json:"check"`
+	// Updated is set when the entry was changed to the redirect target
+	Updated bool `json:"updated,omitempty"`
+}
+
+// CheckLinks requests every link of the stored link entries, at most
+// workers at a time, and records what was found with each link. Each
+// address is requested once however many entries hold it. With update set,
+// links that redirect elsewhere are changed to where they ended, in the
+// entry's text too, and their metadata is fetched again before it returns.
+func (s *EntryService) CheckLinks(workers int, update bool) ([]LinkCheckResult, error) {
+	entries, err := s.storage.LoadFilteredEntries(models.TypeLink)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		for _, link := range entry.Links {
+			if !seen[link.URL] {
+				seen[link.URL] = true
+				urls = append(urls, link.URL)
+			}
+		}
+	}
+	checks := s.checkURLs(urls, workers)
+
+	var results []LinkCheckResult
+	var refetches sync.WaitGroup
+	defer refetches.Wait()
+	for _, entry := range entries {
+		if len(entry.Links) == 0 {
+			continue
+		}
+		updated, err := s.recordChecks(entry.ID, checks, update)
+		if err != nil {
+			return results, err
+		}
+		for _, link := range entry.Links {
+			check := checks[link.URL]
+			results = append(results, LinkCheckResult{
+				Entry:   entry,
+				URL:     link.URL,
+				Check:   check,
+				Updated: update && check.Moved(),
+			})
+		}
+		if update {
+			s.indexLinks(updated)
+			s.fetchLinkMetadata(&updated, &refetches)
+		}
+	}
+	return results, nil
+}
+
+// checkURLs checks urls, at most workers at a time, keyed by URL
+func (s *EntryService) checkURLs(urls []string, workers int) map[string]models.LinkCheck {
+	if workers <= 0 {
+		workers = linkCheckWorkers
+	}
+
+	checked := make([]models.LinkCheck, len(urls))
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			check, err := s.extractor.CheckURL(url)
+			if err != nil {
+				check = models.LinkCheck{Error: err.Error()}
+			}
+			check.CheckedAt = s.now()
+			checked[i] = check
+		}()
+	}
+	wg.Wait()
+
+	checks := make(map[string]models.LinkCheck, len(urls))
+	for i, url := range urls {
+		checks[url] = checked[i]
+	}
+	return checks
+}
+
+// recordChecks stores the checks of the entry's links. With update set a
+// link that moved takes the address it ended on, which is then known to
+// work, and loses the metadata of the old address.
+func (s *EntryService) recordChecks(id string, checks map[string]models.LinkCheck, update bool) (models.Entry, error) {
+	var updated models.Entry
+	err := s.storage.UpdateEntry(id, func(entry *models.Entry) {
+		content, moved := entry.Content, false
+		for i := range entry.Links {
+			link := &entry.Links[i]
+			check, ok := checks[link.URL]
+			if !ok {
+				continue
+			}
+			if update && check.Moved() {
+				content = s.extractor.ReplaceLink(content, link.URL, check.RedirectURL)
+				link.URL = check.RedirectURL
+				link.Metadata = nil
+				check.RedirectURL = ""
+				moved = true
+			}
+			link.Check = &check
+		}
+
+		if moved {
+			entry.Content = content
+			entry.Entities = s.entities.ExtractEntities(content)
+			entry.UpdatedAt = s.now()
+		}
+		updated = *entry
+	})
+	return updated, err
+}
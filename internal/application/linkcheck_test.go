@@ -0,0 +1,99 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckLinks(t *testing.T) {
+	var okRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		okRequests.Add(1)
+		w.Write([]byte(`<title>Fine</title>`))
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<title>New home</title>`))
+	})
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	now := time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local)
+	s := newTestService(t, now)
+	working, _ := s.CreateEntry("read "+srv.URL+"/ok", nil)
+	dead, _ := s.CreateEntry("read "+srv.URL+"/gone and "+srv.URL+"/flaky", nil)
+	moved, _ := s.CreateEntry("read ("+srv.URL+"/old).", nil)
+	again, _ := s.CreateEntry("also "+srv.URL+"/ok", nil)
+	note, _ := s.CreateEntry("remember the milk", nil)
+	s.Wait()
+	okRequests.Store(0)
+
+	results, err := s.CheckLinks(2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("expected a result per link, got %d", len(results))
+	}
+	if n := okRequests.Load(); n != 1 {
+		t.Errorf("expected a link held twice to be checked once, got %d requests", n)
+	}
+
+	found := make(map[string]LinkCheckResult)
+	for _, r := range results {
+		found[strings.TrimPrefix(r.URL, srv.URL)] = r
+	}
+	if r := found["/ok"]; r.Check.Dead() || r.Check.Moved() || r.Check.Status != 200 || !r.Check.CheckedAt.Equal(now) {
+		t.Errorf("expected a working link, got %+v", r.Check)
+	}
+	for _, path := range []string{"/gone", "/flaky"} {
+		if r := found[path]; !r.Check.Dead() || r.Entry.ID != dead.ID {
+			t.Errorf("expected %s to be dead, got %+v", path, r.Check)
+		}
+	}
+	if r := found["/old"]; !r.Check.Moved() || r.Check.RedirectURL != srv.URL+"/new" || r.Updated {
+		t.Errorf("expected /old to have moved to /new, got %+v", r)
+	}
+
+	stored, _ := s.GetEntry(dead.ID)
+	if check := stored.Links[0].Check; check == nil || check.Status != 404 {
+		t.Errorf("expected the check to be stored with the link, got %+v", check)
+	}
+	if stored, _ := s.GetEntry(moved.ID); stored.Links[0].URL != srv.URL+"/old" {
+		t.Errorf("expected a check without update to leave the link, got %+v", stored.Links)
+	}
+	for _, id := range []string{working.ID, again.ID} {
+		if stored, _ := s.GetEntry(id); stored.Links[0].Check == nil {
+			t.Errorf("expected every entry holding /ok to record the check")
+		}
+	}
+	if stored, _ := s.GetEntry(note.ID); len(stored.Links) != 0 {
+		t.Errorf("expected notes without links to be left alone")
+	}
+
+	// Updating rewrites moved links to where they ended
+	if _, err := s.CheckLinks(0, true); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ = s.GetEntry(moved.ID)
+	if stored.Content != "read ("+srv.URL+"/new)." {
+		t.Errorf("expected the text to use the new address, got %q", stored.Content)
+	}
+	link := stored.Links[0]
+	if link.URL != srv.URL+"/new" || link.Title != "New home" || link.Check == nil || link.Check.Moved() {
+		t.Errorf("expected the link moved and fetched again, got %+v", link)
+	}
+}
@@ -0,0 +1,120 @@
+package application
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"stak/internal/models"
+)
+
+// Recategorization is how re-running the categorizer changes a stored entry
+type Recategorization struct {
+	Entry   models.Entry     `json:"entry"` // as it was stored
+	OldType models.EntryType `json:"old_type"`
+	NewType models.EntryType `json:"new_type"`
+	OldTags []string         `json:"old_tags"`
+	NewTags []string         `json:"new_tags"`
+}
+
+// String describes the change, as in "note → todo +todo +task -note"
+func (r Recategorization) String() string {
+	parts := []string{string(r.OldType)}
+	if r.NewType != r.OldType {
+		parts = []string{fmt.Sprintf("%s → %s", r.OldType, r.NewType)}
+	}
+	for _, tag := range r.AddedTags() {
+		parts = append(parts, "+"+tag)
+	}
+	for _, tag := range r.RemovedTags() {
+		parts = append(parts, "-"+tag)
+	}
+	return strings.Join(parts, " ")
+}
+
+// AddedTags returns the tags the entry gains
+func (r Recategorization) AddedTags() []string {
+	return missingFrom(r.NewTags, r.OldTags)
+}
+
+// RemovedTags returns the tags the entry loses
+func (r Recategorization) RemovedTags() []string {
+	return missingFrom(r.OldTags, r.NewTags)
+}
+
+// missingFrom returns the tags in a that are not in b
+func missingFrom(a, b []string) []string {
+	var missing []string
+	for _, tag := range a {
+		if !slices.Contains(b, tag) {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+// Recategorize re-runs the categorizer over the stored entries in filter's
+// date range and returns those whose type or tags would change. Entries
+// categorized by hand are skipped, and tags edited by hand are kept. With
+// apply set the changes are saved; otherwise nothing is written.
+func (s *EntryService) Recategorize(filter EntryFilter, apply bool) ([]Recategorization, error) {
+	entries, err := s.ListEntries(EntryFilter{Since: filter.Since, Until: filter.Until})
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Recategorization
+	for _, entry := range entries {
+		if entry.CategorizedByHand() {
+			continue
+		}
+
+		stored := entry
+		stored.Tags = slices.Clone(entry.Tags)
+		stored.Metadata = maps.Clone(entry.Metadata)
+		s.recategoriseStored(&entry)
+		if entry.Type == stored.Type && sameTags(entry.Tags, stored.Tags) {
+			continue
+		}
+
+		change := Recategorization{
+			Entry:   stored,
+			OldType: stored.Type,
+			NewType: entry.Type,
+			OldTags: stored.Tags,
+			NewTags: entry.Tags,
+		}
+		if apply {
+			err := s.storage.UpdateEntry(entry.ID, func(current *models.Entry) {
+				if current.CategorizedByHand() {
+					return
+				}
+				s.recategoriseStored(current)
+				change.NewType, change.NewTags = current.Type, current.Tags
+				entry = *current
+			})
+			if err != nil {
+				return changes, err
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// recategoriseStored runs the categorizer over a stored entry, keeping its
+// todo status, todo attributes and any tags edited by hand
+func (s *EntryService) recategoriseStored(entry *models.Entry) {
+	tags := entry.Tags
+	s.recategorise(entry, nil, entry.TodoStatus)
+	promoteToTodo(entry)
+	if entry.TagsEditedByHand() {
+		entry.Tags = tags
+	}
+}
+
+// sameTags reports whether a and b hold the same tags in any order
+func sameTags(a, b []string) bool {
+	return len(a) == len(b) && len(missingFrom(a, b)) == 0
+}
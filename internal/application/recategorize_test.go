@@ -0,0 +1,94 @@
+package application
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"stak/internal/config"
+	"stak/internal/models"
+	"stak/pkg/categorizer"
+)
+
+func TestRecategorize(t *testing.T) {
+	now := time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local)
+	s := newTestService(t, now)
+
+	plain, _ := s.CreateEntry("garden planner app", nil)
+	byHand, _ := s.CreateEntry("recipe box app", nil)
+	if _, err := s.SetEntryType(byHand.ID, models.TypeQuestion); err != nil {
+		t.Fatal(err)
+	}
+	unchanged, _ := s.CreateEntry("need to water the plants", nil)
+
+	// The rules learn about app ideas after the entries were captured
+	s.categorizer = categorizer.NewWithConfig(config.CategoriesConfig{Rules: []config.CategoryRule{
+		{Name: "apps", Type: "idea", Match: "word", Patterns: []string{"app"}, Precedence: 70, Tags: []string{"idea"}},
+	}})
+
+	preview, err := s.Recategorize(EntryFilter{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview) != 1 || preview[0].Entry.ID != plain.ID {
+		t.Fatalf("expected only the uncorrected note to change, got %v", preview)
+	}
+	if got := preview[0].String(); got != "note → idea +idea -note" {
+		t.Errorf("unexpected change description %q", got)
+	}
+	if !maps.Equal(preview[0].Entry.Metadata, plain.Metadata) {
+		t.Errorf("expected the preview to show the stored metadata %v, got %v", plain.Metadata, preview[0].Entry.Metadata)
+	}
+	if stored, _ := s.GetEntry(plain.ID); stored.Type != models.TypeNote {
+		t.Errorf("expected a dry run to leave the entry alone, got %s", stored.Type)
+	}
+
+	if _, err := s.Recategorize(EntryFilter{}, true); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := s.GetEntry(plain.ID); stored.Type != models.TypeIdea {
+		t.Errorf("expected the note to become an idea, got %s", stored.Type)
+	}
+	if stored, _ := s.GetEntry(byHand.ID); stored.Type != models.TypeQuestion {
+		t.Errorf("expected the hand-picked type to be kept, got %s", stored.Type)
+	}
+	if stored, _ := s.GetEntry(unchanged.ID); stored.Type != models.TypeTodo || stored.TodoStatus != models.TodoPending {
+		t.Errorf("expected the todo to be kept, got %s %q", stored.Type, stored.TodoStatus)
+	}
+
+	again, _ := s.Recategorize(EntryFilter{}, false)
+	if len(again) != 0 {
+		t.Errorf("expected nothing left to change, got %v", again)
+	}
+}
+
+func TestRecategorizeKeepsTagsEditedByHand(t *testing.T) {
+	now := time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local)
+	s := newTestService(t, now)
+
+	entry, _ := s.CreateEntry("garden planner app", nil)
+	// As when the tags line of the day file body is edited
+	err := s.storage.UpdateEntry(entry.ID, func(e *models.Entry) {
+		e.Tags = []string{"note", "garden"}
+		e.MarkTagsEditedByHand()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.categorizer = categorizer.NewWithConfig(config.CategoriesConfig{Rules: []config.CategoryRule{
+		{Name: "apps", Type: "idea", Match: "word", Patterns: []string{"app"}, Precedence: 70, Tags: []string{"idea"}},
+	}})
+
+	if _, err := s.Recategorize(EntryFilter{}, true); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := s.GetEntry(entry.ID)
+	if stored.Type != models.TypeIdea {
+		t.Errorf("expected the type to be recategorized, got %s", stored.Type)
+	}
+	if !slices.Equal(stored.Tags, []string{"note", "garden"}) {
+		t.Errorf("expected the hand-edited tags to be kept, got %v", stored.Tags)
+	}
+}
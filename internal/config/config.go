@@ -2,10 +2,16 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
+	"stak/internal/models"
 )
 
 type Config struct {
@@ -15,6 +21,247 @@ type Config struct {
 	DateFormat  string `yaml:"date_format"`
 	AutoSave    bool   `yaml:"auto_save"`
 	FuzzySearch bool   `yaml:"fuzzy_search"`
+	// RecategorizeOnEdit re-runs categorization and link extraction when an
+	// edit changes an entry's content
+	RecategorizeOnEdit bool `yaml:"recategorize_on_edit"`
+	// Categorizer picks how entry types are chosen: "rules" for the
+	// categorization rules alone, "learning" for a model trained on the
+	// stored entries and on types chosen by hand, or "command" to run
+	// CategorizerCommand
+	Categorizer string `yaml:"categorizer"`
+	// Locales picks the keyword packs the rules recognise, such as "en"
+	// and "es" for notes written in English and Spanish. The packs ship
+	// with the categorizer, which reports unknown ones.
+	Locales []string `yaml:"locales"`
+	// CategorizerCommand is the external categorizer used by "command"
+	CategorizerCommand CategorizerCommand `yaml:"categorizer_command,omitempty"`
+	// Categories adds to or replaces the shipped categorization rules
+	Categories CategoriesConfig `yaml:"categories"`
+	// Entities adds to or changes the shipped entity patterns
+	Entities EntitiesConfig `yaml:"entities"`
+	// Links controls how links are normalized and fetched for their metadata
+	Links LinksConfig `yaml:"links,omitempty"`
+}
+
+// LinksConfig controls how links are normalized and how their pages are
+// fetched for their title and other metadata. Zero values use the defaults
+// noted on each field.
+type LinksConfig struct {
+	// UserAgent is sent with every request, identifying stak when unset
+	UserAgent string `yaml:"user_agent,omitempty"`
+	// Timeout bounds each fetch, five seconds when unset
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// MaxRedirects is how many redirects a fetch follows, five when unset
+	MaxRedirects int `yaml:"max_redirects,omitempty"`
+	// MaxBytes caps how much of a page is read, 1 MiB when unset
+	MaxBytes int64 `yaml:"max_bytes,omitempty"`
+	// CacheTTL is how long fetched metadata is reused before a link is
+	// fetched again, a week when unset
+	CacheTTL time.Duration `yaml:"cache_ttl,omitempty"`
+	// Proxy is an http, https or socks5 proxy URL. When unset the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply.
+	Proxy string `yaml:"proxy,omitempty"`
+	// StripParams are the query parameters removed from captured links,
+	// where a trailing * matches any suffix. When unset utm_* parameters
+	// and common click IDs are removed.
+	StripParams []string `yaml:"strip_params,omitempty"`
+}
+
+// Validate reports the first setting that cannot be used
+func (c LinksConfig) Validate() error {
+	if c.Timeout < 0 || c.MaxRedirects < 0 || c.MaxBytes < 0 || c.CacheTTL < 0 {
+		return fmt.Errorf("links timeout, max_redirects, max_bytes and cache_ttl cannot be negative")
+	}
+	for _, param := range c.StripParams {
+		if strings.TrimSuffix(param, "*") == "" {
+			return fmt.Errorf("links strip_params: %q matches every parameter", param)
+		}
+	}
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err != nil {
+			return fmt.Errorf("links proxy: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+			return fmt.Errorf("links proxy %q: use an http, https or socks5 URL", c.Proxy)
+		}
+	}
+	return nil
+}
+
+// EntitiesConfig customises which entities are recognised in entry text.
+// Patterns are layered over the shipped ones: a pattern with a shipped
+// kind changes only the fields it sets, and any other kind adds a pattern.
+type EntitiesConfig struct {
+	Patterns []EntityPattern `yaml:"patterns,omitempty"`
+}
+
+// EntityPattern recognises one kind of entity
+type EntityPattern struct {
+	Kind    string `yaml:"kind"`
+	Pattern string `yaml:"pattern,omitempty"`
+	// URL is a link template for matches: $0 is the whole match, and $1 or
+	// ${name} a group of Pattern
+	URL           string `yaml:"url,omitempty"`
+	CaseSensitive bool   `yaml:"case_sensitive,omitempty"`
+	Disabled      bool   `yaml:"disabled,omitempty"`
+}
+
+// Validate reports the first pattern that cannot be used
+func (c EntitiesConfig) Validate() error {
+	for i, pattern := range c.Patterns {
+		if pattern.Kind == "" {
+			return fmt.Errorf("entities pattern %d has no kind", i+1)
+		}
+		if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			return fmt.Errorf("entities pattern %q: %w", pattern.Kind, err)
+		}
+	}
+	return nil
+}
+
+// Merge layers the config's patterns over shipped
+func (c EntitiesConfig) Merge(shipped []EntityPattern) []EntityPattern {
+	kind := func(p EntityPattern) string { return p.Kind }
+	return layer(shipped, c.Patterns, kind, func(base *EntityPattern, override EntityPattern) {
+		if override.Pattern != "" {
+			base.Pattern = override.Pattern
+		}
+		if override.URL != "" {
+			base.URL = override.URL
+		}
+		if override.CaseSensitive {
+			base.CaseSensitive = true
+		}
+		base.Disabled = override.Disabled
+	})
+}
+
+// Categorizers lists the valid Config.Categorizer values
+var Categorizers = []string{"rules", "learning", "command"}
+
+// CategorizerCommand runs an executable to categorise entries. It is sent
+// the entry as JSON on stdin and prints the type, tags and metadata as JSON
+// on stdout; when it fails or times out the rules are used instead.
+type CategorizerCommand struct {
+	Path string   `yaml:"path"`
+	Args []string `yaml:"args,omitempty"`
+	// Timeout bounds each run, two seconds when unset
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// CategoriesConfig customises how captured text is categorised. Rules are
+// layered over the shipped defaults: a rule named like a default changes
+// only the fields it sets, and any other name adds a new rule.
+type CategoriesConfig struct {
+	// ReplaceDefaults drops the shipped rules so only Rules apply
+	ReplaceDefaults bool           `yaml:"replace_defaults"`
+	Rules           []CategoryRule `yaml:"rules,omitempty"`
+}
+
+// CategoryRule matches captured text and sets its type, its tags or both.
+// Rules with a type are tried in order of precedence, highest first, and the
+// first match decides the entry's type. Rules without a type only add tags,
+// and every one that matches applies.
+type CategoryRule struct {
+	Name string `yaml:"name"`
+	// Type is the entry type to assign, or empty for a tag-only rule
+	Type string `yaml:"type,omitempty"`
+	// Match is how Patterns are compared with the text: regex, prefix,
+	// keyword (anywhere in the text), word (whole words and phrases, ignoring
+	// case, punctuation and URLs) or any
+	Match      string   `yaml:"match,omitempty"`
+	Patterns   []string `yaml:"patterns,omitempty"`
+	Precedence int      `yaml:"precedence,omitempty"`
+	Tags       []string `yaml:"tags,omitempty"`
+	// Types limits a tag-only rule to entries of these types
+	Types         []string `yaml:"types,omitempty"`
+	CaseSensitive bool     `yaml:"case_sensitive,omitempty"`
+	Disabled      bool     `yaml:"disabled,omitempty"`
+}
+
+// CategoryMatchKinds lists the valid CategoryRule.Match values
+var CategoryMatchKinds = []string{"regex", "prefix", "keyword", "word", "any"}
+
+// Validate reports the first rule that cannot be used
+func (c CategoriesConfig) Validate() error {
+	for i, rule := range c.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("categories rule %d has no name", i+1)
+		}
+		if rule.Match != "" && !slices.Contains(CategoryMatchKinds, rule.Match) {
+			return fmt.Errorf("categories rule %q: unknown match %q, use one of %s",
+				rule.Name, rule.Match, strings.Join(CategoryMatchKinds, ", "))
+		}
+		for _, name := range append([]string{rule.Type}, rule.Types...) {
+			if _, ok := models.ParseEntryType(name); name != "" && !ok {
+				return fmt.Errorf("categories rule %q: unknown entry type %q", rule.Name, name)
+			}
+		}
+		if rule.Match == "regex" {
+			for _, pattern := range rule.Patterns {
+				if _, err := regexp.Compile(pattern); err != nil {
+					return fmt.Errorf("categories rule %q: %w", rule.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Merge layers the config's rules over shipped, or returns them alone when
+// ReplaceDefaults is set
+func (c CategoriesConfig) Merge(shipped []CategoryRule) []CategoryRule {
+	if c.ReplaceDefaults {
+		shipped = nil
+	}
+	name := func(r CategoryRule) string { return r.Name }
+	return layer(shipped, c.Rules, name, func(base *CategoryRule, override CategoryRule) {
+		if override.Type != "" {
+			base.Type = override.Type
+		}
+		if override.Match != "" {
+			base.Match = override.Match
+		}
+		if override.Patterns != nil {
+			base.Patterns = override.Patterns
+		}
+		if override.Precedence != 0 {
+			base.Precedence = override.Precedence
+		}
+		if override.Tags != nil {
+			base.Tags = override.Tags
+		}
+		if override.Types != nil {
+			base.Types = override.Types
+		}
+		if override.CaseSensitive {
+			base.CaseSensitive = true
+		}
+		base.Disabled = override.Disabled
+	})
+}
+
+// layer returns defaults with overrides layered onto them. An override
+// keyed like a default is applied to it, changing only the fields it sets;
+// any other override is added after the defaults.
+func layer[T any](defaults, overrides []T, key func(T) string, apply func(base *T, override T)) []T {
+	merged := slices.Clone(defaults)
+	index := make(map[string]int, len(merged))
+	for i, item := range merged {
+		index[key(item)] = i
+	}
+
+	for _, override := range overrides {
+		i, ok := index[key(override)]
+		if !ok {
+			index[key(override)] = len(merged)
+			merged = append(merged, override)
+			continue
+		}
+		apply(&merged[i], override)
+	}
+	return merged
 }
 
 func DefaultConfig() *Config {
@@ -29,6 +276,9 @@ func DefaultConfig() *Config {
 		DateFormat:  "2006-01-02",
 		AutoSave:    true,
 		FuzzySearch: true,
+		RecategorizeOnEdit: true,
+		Categorizer:        "rules",
+		Locales:            []string{"en"},
 	}
 }
 
@@ -62,6 +312,23 @@ func LoadConfig(configPath string) (*Config, error) {
 			}
 		}
 	}
+
+	if !slices.Contains(Categorizers, config.Categorizer) {
+		return nil, fmt.Errorf("invalid config file %s: unknown categorizer %q, use one of %s",
+			configPath, config.Categorizer, strings.Join(Categorizers, ", "))
+	}
+	if config.Categorizer == "command" && config.CategorizerCommand.Path == "" {
+		return nil, fmt.Errorf("invalid config file %s: categorizer command needs categorizer_command.path", configPath)
+	}
+	if err := config.Categories.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
+	if err := config.Entities.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
+	if err := config.Links.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
 	
 	// Expand relative paths to absolute
 	if !filepath.IsAbs(config.DataDir) {
@@ -104,7 +371,10 @@ func CreateSampleConfig(path string) error {
 		DateFormat:  "2006-01-02",
 		AutoSave:    true,
 		FuzzySearch: true,
+		RecategorizeOnEdit: true,
+		Categorizer:        "rules",
+		Locales:            []string{"en"},
 	}
 	
 	return sampleConfig.Save(path)
-}
\ No newline at end of file
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Metadata keys recording why an entry got its type
+const (
+	categoryConfidenceKey = "category_confidence"
+	categoryReasonsKey    = "category_reasons"
+	categoryRunnersUpKey  = "category_runners_up"
+)
+
+// TypeScore is an entry type with the confidence a categorizer gave it
+type TypeScore struct {
+	Type       EntryType `json:"type"`
+	Confidence float64   `json:"confidence"`
+}
+
+// Categorization explains a categorizer's choice: the type it picked, how
+// sure it was, what made it choose that and the types it ranked next.
+type Categorization struct {
+	Type       EntryType   `json:"type"`
+	Confidence float64     `json:"confidence"`
+	Reasons    []string    `json:"reasons,omitempty"`
+	RunnersUp  []TypeScore `json:"runners_up,omitempty"`
+}
+
+// SetCategorization records c in the entry's metadata, replacing any
+// earlier explanation
+func (e *Entry) SetCategorization(c Categorization) {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]string)
+	}
+	delete(e.Metadata, categoryReasonsKey)
+	delete(e.Metadata, categoryRunnersUpKey)
+
+	e.Metadata[categoryConfidenceKey] = strconv.FormatFloat(c.Confidence, 'f', 2, 64)
+	if len(c.Reasons) > 0 {
+		e.Metadata[categoryReasonsKey] = strings.Join(c.Reasons, "; ")
+	}
+	if len(c.RunnersUp) > 0 {
+		scores := make([]string, len(c.RunnersUp))
+		for i, s := range c.RunnersUp {
+			scores[i] = fmt.Sprintf("%s:%.2f", s.Type, s.Confidence)
+		}
+		e.Metadata[categoryRunnersUpKey] = strings.Join(scores, ",")
+	}
+}
+
+// Categorization returns the explanation recorded for the entry's type, if
+// there is one
+func (e Entry) Categorization() (Categorization, bool) {
+	confidence, err := strconv.ParseFloat(e.Metadata[categoryConfidenceKey], 64)
+	if err != nil {
+		return Categorization{}, false
+	}
+
+	c := Categorization{Type: e.Type, Confidence: confidence}
+	if reasons := e.Metadata[categoryReasonsKey]; reasons != "" {
+		c.Reasons = strings.Split(reasons, "; ")
+	}
+	for _, score := range strings.Split(e.Metadata[categoryRunnersUpKey], ",") {
+		name, value, ok := strings.Cut(score, ":")
+		entryType, known := ParseEntryType(name)
+		if !ok || !known {
+			continue
+		}
+		confidence, _ := strconv.ParseFloat(value, 64)
+		c.RunnersUp = append(c.RunnersUp, TypeScore{Type: entryType, Confidence: confidence})
+	}
+	return c, true
+}
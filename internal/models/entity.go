@@ -0,0 +1,22 @@
+package models
+
+// Entity is something recognised in an entry's text besides its link, like
+// an email address, a ticket ID or a GitHub reference
+type Entity struct {
+	// Kind names the pattern that found the entity, such as "email"
+	Kind  string `yaml:"kind" json:"kind"`
+	Value string `yaml:"value" json:"value"`
+	// URL opens the entity, when its kind has a link template
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+}
+
+// EntityURL returns the first entity link, for entries without a URL of
+// their own
+func (e Entry) EntityURL() string {
+	for _, entity := range e.Entities {
+		if entity.URL != "" {
+			return entity.URL
+		}
+	}
+	return ""
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -16,6 +17,19 @@ const (
 	TypeIdea     EntryType = "idea"
 )
 
+// EntryTypes lists every entry type in display order
+var EntryTypes = []EntryType{TypeNote, TypeTodo, TypeLink, TypeCode, TypeQuestion, TypeMeeting, TypeIdea}
+
+// ParseEntryType returns the entry type named by s
+func ParseEntryType(s string) (EntryType, bool) {
+	for _, t := range EntryTypes {
+		if string(t) == s {
+			return t, true
+		}
+	}
+	return "", false
+}
+
 type TodoStatus string
 
 const (
@@ -24,14 +38,66 @@ const (
 	TodoCancelled TodoStatus = "cancelled"
 )
 
+// TodoStatuses lists every todo status in lifecycle order
+var TodoStatuses = []TodoStatus{TodoPending, TodoCompleted, TodoCancelled}
+
+type Priority string
+
+const (
+	PriorityHigh   Priority = "high"
+	PriorityMedium Priority = "medium"
+	PriorityLow    Priority = "low"
+)
+
+// ParsePriority accepts a priority name, its first letter or 1-3
+func ParsePriority(s string) (Priority, bool) {
+	switch strings.ToLower(s) {
+	case "high", "h", "1":
+		return PriorityHigh, true
+	case "medium", "med", "m", "2":
+		return PriorityMedium, true
+	case "low", "l", "3":
+		return PriorityLow, true
+	}
+	return "", false
+}
+
+// Rank orders priorities for sorting, most urgent first; unset sorts last
+func (p Priority) Rank() int {
+	switch p {
+	case PriorityHigh:
+		return 0
+	case PriorityMedium:
+		return 1
+	case PriorityLow:
+		return 2
+	}
+	return 3
+}
+
+// ParseTodoStatus returns the todo status named by s
+func ParseTodoStatus(s string) (TodoStatus, bool) {
+	for _, status := range TodoStatuses {
+		if string(status) == s {
+			return status, true
+		}
+	}
+	return "", false
+}
+
 type Entry struct {
 	ID          string            `yaml:"id" json:"id"`
 	Content     string            `yaml:"content" json:"content"`
 	Type        EntryType         `yaml:"type" json:"type"`
 	Tags        []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
-	URL         string            `yaml:"url,omitempty" json:"url,omitempty"`
-	URLTitle    string            `yaml:"url_title,omitempty" json:"url_title,omitempty"`
+	People      []string          `yaml:"people,omitempty" json:"people,omitempty"`
+	Projects    []string          `yaml:"projects,omitempty" json:"projects,omitempty"`
+	Links       []Link            `yaml:"links,omitempty" json:"links,omitempty"`
+	Entities    []Entity          `yaml:"entities,omitempty" json:"entities,omitempty"`
 	TodoStatus  TodoStatus        `yaml:"todo_status,omitempty" json:"todo_status,omitempty"`
+	Priority    Priority          `yaml:"priority,omitempty" json:"priority,omitempty"`
+	DueAt       *time.Time        `yaml:"due_at,omitempty" json:"due_at,omitempty"`
+	CompletedAt *time.Time        `yaml:"completed_at,omitempty" json:"completed_at,omitempty"`
 	CreatedAt   time.Time         `yaml:"created_at" json:"created_at"`
 	UpdatedAt   time.Time         `yaml:"updated_at" json:"updated_at"`
 	Metadata    map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
@@ -43,9 +109,13 @@ type DayFile struct {
 }
 
 func NewEntry(content string) *Entry {
-	now := time.Now()
+	return NewEntryAt(content, time.Now())
+}
+
+// NewEntryAt returns an entry captured at now
+func NewEntryAt(content string, now time.Time) *Entry {
 	return &Entry{
-		ID:        generateID(),
+		ID:        generateID(now),
 		Content:   content,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -54,8 +124,101 @@ func NewEntry(content string) *Entry {
 	}
 }
 
-func generateID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(6)
+// SetTodoStatus changes a todo's status, recording when it was completed
+func (e *Entry) SetTodoStatus(status TodoStatus, now time.Time) {
+	e.TodoStatus = status
+	e.UpdatedAt = now
+	if status == TodoCompleted {
+		e.CompletedAt = &now
+	} else {
+		e.CompletedAt = nil
+	}
+}
+
+// HasDueTime reports whether the due date carries a time of day. Due dates
+// without one are stored at midnight and last the whole day.
+func (e Entry) HasDueTime() bool {
+	if e.DueAt == nil {
+		return false
+	}
+	h, m, s := e.DueAt.Clock()
+	return h != 0 || m != 0 || s != 0
+}
+
+// DueDeadline is the moment a due todo becomes overdue
+func (e Entry) DueDeadline() time.Time {
+	if e.DueAt == nil {
+		return time.Time{}
+	}
+	if e.HasDueTime() {
+		return *e.DueAt
+	}
+	return e.DueAt.AddDate(0, 0, 1)
+}
+
+// IsOverdue reports whether a pending todo has passed its due date
+func (e Entry) IsOverdue(now time.Time) bool {
+	if e.Type != TypeTodo || e.DueAt == nil {
+		return false
+	}
+	if e.TodoStatus == TodoCompleted || e.TodoStatus == TodoCancelled {
+		return false
+	}
+	return !now.Before(e.DueDeadline())
+}
+
+// FormatDue renders the due date, with the time only when one was given
+func (e Entry) FormatDue() string {
+	if e.DueAt == nil {
+		return ""
+	}
+	if e.HasDueTime() {
+		return e.DueAt.Format("2006-01-02 15:04")
+	}
+	return e.DueAt.Format("2006-01-02")
+}
+
+// MarkCategorizedByHand records that the user chose the entry's type, so
+// automatic recategorization leaves it alone
+func (e *Entry) MarkCategorizedByHand() {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]string)
+	}
+	e.Metadata["categorized_by"] = "user"
+}
+
+// CategorizedByHand reports whether the user chose the entry's type
+func (e Entry) CategorizedByHand() bool {
+	return e.Metadata["categorized_by"] == "user"
+}
+
+// MarkTagsEditedByHand records that the user edited the entry's tags, so
+// automatic recategorization keeps them
+func (e *Entry) MarkTagsEditedByHand() {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]string)
+	}
+	e.Metadata["tags_by"] = "user"
+}
+
+// TagsEditedByHand reports whether the user edited the entry's tags
+func (e Entry) TagsEditedByHand() bool {
+	return e.Metadata["tags_by"] == "user"
+}
+
+// ReservedMetadataKey reports whether stak itself keeps key in an entry's
+// metadata, so categorizers must not set it
+func ReservedMetadataKey(key string) bool {
+	switch key {
+	case "categorized_by", "tags_by", "archived_at", "deleted_at",
+		categoryConfidenceKey, categoryReasonsKey, categoryRunnersUpKey:
+		return true
+	}
+	return false
+}
+
+func generateID(now time.Time) string {
+	return now.Format("20060102150405") + "-" + randomString(6)
 }
 
 func randomString(n int) string {
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// LinkMetadata is what a linked page says about itself, read from its
+// <title>, OpenGraph and Twitter card tags when the entry is captured
+type LinkMetadata struct {
+	Title       string `yaml:"title,omitempty" json:"title,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	SiteName    string `yaml:"site_name,omitempty" json:"site_name,omitempty"`
+	// CanonicalURL is the address the page names as its own, which may
+	// differ from the captured URL
+	CanonicalURL string `yaml:"canonical_url,omitempty" json:"canonical_url,omitempty"`
+	FaviconURL   string `yaml:"favicon_url,omitempty" json:"favicon_url,omitempty"`
+	ImageURL     string `yaml:"image_url,omitempty" json:"image_url,omitempty"`
+	// ContentType is the response's media type, such as "text/html" or
+	// "application/pdf"
+	ContentType string `yaml:"content_type,omitempty" json:"content_type,omitempty"`
+}
+
+// Link is a web address written in an entry, with the title and metadata
+// fetched from its page
+type Link struct {
+	URL      string        `yaml:"url" json:"url"`
+	Title    string        `yaml:"title,omitempty" json:"title,omitempty"`
+	Metadata *LinkMetadata `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	// Check is the outcome of the last link rot check, nil until checked
+	Check *LinkCheck `yaml:"check,omitempty" json:"check,omitempty"`
+}
+
+// LinkCheck records whether a link still worked when it was last checked
+type LinkCheck struct {
+	// Status is the HTTP status the link ended on, 0 when the request failed
+	Status int `yaml:"status,omitempty" json:"status,omitempty"`
+	// RedirectURL is where redirects led, when that is another address
+	RedirectURL string `yaml:"redirect_url,omitempty" json:"redirect_url,omitempty"`
+	// Error says why the request failed, such as a timeout or an unknown host
+	Error     string    `yaml:"error,omitempty" json:"error,omitempty"`
+	CheckedAt time.Time `yaml:"checked_at" json:"checked_at"`
+}
+
+// Dead reports whether the link could not be fetched or ended on an error
+// status
+func (c LinkCheck) Dead() bool {
+	return c.Error != "" || c.Status >= 400
+}
+
+// Moved reports whether the link works but redirects to another address
+func (c LinkCheck) Moved() bool {
+	return !c.Dead() && c.RedirectURL != ""
+}
+
+// SetLinks replaces the entry's links with urls, in order. Links it
+// already had keep their title and metadata.
+func (e *Entry) SetLinks(urls []string) {
+	var links []Link
+	for _, url := range urls {
+		link := Link{URL: url}
+		for _, existing := range e.Links {
+			if existing.URL == url {
+				link = existing
+				break
+			}
+		}
+		links = append(links, link)
+	}
+	e.Links = links
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// InlineTokenRegex matches todo.txt style #tag, @person and +project tokens.
+// A token starts after whitespace and with a letter, so URLs, emails, issue
+// numbers like #42 and markdown headings are left alone.
+var InlineTokenRegex = regexp.MustCompile(`(^|\s)([#@+])(\p{L}[\p{L}\p{N}_-]*(?:[./][\p{L}\p{N}_-]+)*)`)
+
+// LinkRegex matches web addresses as they are written, starting with a
+// scheme or with "www.". Surrounding punctuation is not trimmed.
+var LinkRegex = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+var codeSpanRegex = regexp.MustCompile("(?s)```.*?```|`[^`\n]+`")
+
+// QuotedSpans returns the start and end offsets of the code spans, fenced
+// code blocks and links in content. Their text is quoted rather than
+// written for stak, so tokens and dates inside them are not read.
+func QuotedSpans(content string) [][]int {
+	spans := codeSpanRegex.FindAllStringIndex(content, -1)
+	return append(spans, LinkRegex.FindAllStringIndex(content, -1)...)
+}
+
+// InSpan reports whether content[start:end] overlaps one of spans
+func InSpan(spans [][]int, start, end int) bool {
+	for _, span := range spans {
+		if start < span[1] && span[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+// InlineTokens holds the tokens written in an entry's text, lower cased
+type InlineTokens struct {
+	Tags     []string
+	People   []string
+	Projects []string
+}
+
+// ParseInlineTokens finds the #tag, @person and +project tokens in content,
+// ignoring anything inside code spans
+func ParseInlineTokens(content string) InlineTokens {
+	var tokens InlineTokens
+	content = codeSpanRegex.ReplaceAllString(content, " ")
+
+	for _, match := range InlineTokenRegex.FindAllStringSubmatch(content, -1) {
+		value := strings.ToLower(match[3])
+		switch match[2] {
+		case "#":
+			tokens.Tags = appendUnique(tokens.Tags, value)
+		case "@":
+			tokens.People = appendUnique(tokens.People, value)
+		case "+":
+			tokens.Projects = appendUnique(tokens.Projects, value)
+		}
+	}
+	return tokens
+}
+
+// SplitInlineTokens separates the tokens in a search query from its text
+func SplitInlineTokens(query string) (string, InlineTokens) {
+	tokens := ParseInlineTokens(query)
+	rest := InlineTokenRegex.ReplaceAllString(query, "$1")
+	return strings.Join(strings.Fields(rest), " "), tokens
+}
+
+// ApplyInlineTokens adds the tokens in the entry's content to its tags and
+// replaces its people and projects, which only ever come from the text
+func (e *Entry) ApplyInlineTokens() {
+	tokens := ParseInlineTokens(e.Content)
+	for _, tag := range tokens.Tags {
+		e.Tags = appendUnique(e.Tags, tag)
+	}
+	e.People = tokens.People
+	e.Projects = tokens.Projects
+}
+
+// HasInlineTokens reports whether the entry carries every token in tokens
+func (e Entry) HasInlineTokens(tokens InlineTokens) bool {
+	return containsAll(e.Tags, tokens.Tags) &&
+		containsAll(e.People, tokens.People) &&
+		containsAll(e.Projects, tokens.Projects)
+}
+
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if strings.EqualFold(h, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -2,7 +2,15 @@ package ports
 
 import "stak/internal/models"
 
-// CategorizerPort defines the interface for entry categorization
+// CategorizerPort defines the interface for entry categorization.
+// CategoriseEntry sets the entry's type and tags and explains the choice.
 type CategorizerPort interface {
-	CategoriseEntry(entry *models.Entry)
-}
\ No newline at end of file
+	CategoriseEntry(entry *models.Entry) models.Categorization
+}
+
+// CategoryLearnerPort is implemented by categorizers that learn from the
+// types users choose by hand. Train learns the stored entries not seen yet.
+type CategoryLearnerPort interface {
+	Learn(entry models.Entry) error
+	Train(entries []models.Entry) error
+}
@@ -0,0 +1,20 @@
+package ports
+
+import "time"
+
+// DateMatch is a date expression found in captured text
+type DateMatch struct {
+	Time    time.Time // midnight on the matched day unless HasTime is set
+	HasTime bool
+	Text    string // the text with the expression removed
+	// Definite is set when the expression names its day whichever way the
+	// text looks, as "tomorrow", "next friday", "in 3 days", "on
+	// 2025-10-01" and a day with a time of day do. "On monday" may be
+	// looking back.
+	Definite bool
+}
+
+// DateParserPort defines the interface for natural-language date parsing
+type DateParserPort interface {
+	ParseDate(text string, now time.Time) (DateMatch, bool)
+}
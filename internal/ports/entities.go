@@ -0,0 +1,9 @@
+package ports
+
+import "stak/internal/models"
+
+// EntityExtractorPort finds structured entities such as email addresses,
+// ticket IDs and commit SHAs in entry text
+type EntityExtractorPort interface {
+	ExtractEntities(content string) []models.Entity
+}
@@ -1,6 +1,23 @@
 package ports
 
+import "stak/internal/models"
+
 // ExtractorPort defines the interface for link extraction and metadata
 type ExtractorPort interface {
-	GetURLTitle(url string) (string, error)
-}
\ No newline at end of file
+	// ExtractLinks returns the web addresses written in content,
+	// normalized, in order and without repeats
+	ExtractLinks(content string) []string
+	// NormalizeURL returns the form links are stored and compared in,
+	// without tracking parameters and other incidental differences
+	NormalizeURL(url string) string
+	// GetURLMetadata fetches url and reads its title, description, site
+	// name, canonical URL, favicon and content type
+	GetURLMetadata(url string) (models.LinkMetadata, error)
+	// CheckURL requests url, following redirects, and reports the status
+	// it ended on and where it was redirected. An error means no response
+	// was received.
+	CheckURL(url string) (models.LinkCheck, error)
+	// ReplaceLink rewrites each address in content that normalizes to url
+	// as replacement, keeping the text around it
+	ReplaceLink(content, url, replacement string) string
+}
@@ -0,0 +1,48 @@
+package ports
+
+import (
+	"errors"
+	"time"
+
+	"stak/internal/models"
+)
+
+// ErrLinkGone marks a fetch that failed for good, such as a 404, which is
+// not worth retrying
+var ErrLinkGone = errors.New("link is gone")
+
+// LinkRetry is a link whose metadata could not be fetched, queued to be
+// tried again
+type LinkRetry struct {
+	EntryID     string    `json:"entry_id"`
+	URL         string    `json:"url"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// LinkCachePort remembers fetched link metadata, so a URL is not fetched
+// again for every entry linking to it, and queues the fetches that failed
+type LinkCachePort interface {
+	// Lookup returns url's metadata if it was fetched recently enough
+	Lookup(url string) (models.LinkMetadata, bool)
+	Store(url string, meta models.LinkMetadata) error
+	// Enqueue records that fetching url for the entry failed with err, to
+	// be tried again after a delay that grows with each attempt. Errors
+	// wrapping ErrLinkGone are not retried.
+	Enqueue(entryID, url string, err error) error
+	// Due returns the queued fetches ready to be tried again, holding them
+	// back from later calls until the next attempt is due
+	Due() []LinkRetry
+	// Dequeue drops a queued fetch, once it succeeded or no longer applies
+	Dequeue(entryID, url string) error
+	// Holders returns the IDs of the entries recorded as holding the link
+	// url, and false when the index has not been built yet
+	Holders(url string) ([]string, bool)
+	// Index records the links the entry holds, replacing those recorded
+	// before. Nothing is recorded until Reindex has built the index.
+	Index(entryID string, urls []string) error
+	// Reindex replaces the index with holders, the IDs of the entries
+	// holding each link
+	Reindex(holders map[string][]string) error
+}
@@ -1,14 +1,42 @@
 package ports
 
-import "stak/internal/models"
+import (
+	"errors"
+	"time"
+
+	"stak/internal/models"
+)
+
+// ErrEntryNotFound is returned when no stored entry has the requested ID
+var ErrEntryNotFound = errors.New("entry not found")
+
+// ErrBodyConflict is returned when hand edits to a day file's markdown body
+// cannot be merged with its front matter. Such a file is only read from its
+// front matter and is not written until the conflict is resolved.
+var ErrBodyConflict = errors.New("day file body conflicts with front matter")
 
 // StoragePort defines the interface for storage operations
 type StoragePort interface {
 	Initialize() error
 	SaveEntry(entry *models.Entry) error
-	SaveEntryForTomorrow(entry *models.Entry) error
+	UpdateEntry(id string, update func(entry *models.Entry)) error
+	LoadEntry(id string) (*models.Entry, error)
 	LoadTodayEntries() ([]models.Entry, error)
+	LoadEntriesForDate(date time.Time) ([]models.Entry, error)
+	LoadEntriesBetween(start, end time.Time) ([]models.Entry, error)
 	LoadAllEntries() ([]models.Entry, error)
 	LoadFilteredEntries(entryType models.EntryType) ([]models.Entry, error)
 	SearchEntries(query string, linksOnly bool) ([]models.Entry, error)
+	DeleteEntry(id string) error
+	ArchiveEntry(id string) error
+	RestoreEntry(id string) error
+	LoadTrashedEntries() ([]models.Entry, error)
+	LoadArchivedEntries() ([]models.Entry, error)
+	// Conflicts returns the body conflicts found by the loads so far, one
+	// per day file, until the file loads cleanly again
+	Conflicts() []error
+	// ResolveConflicts rewrites the day files with a body conflict from
+	// their front matter, keeping a .bak copy of each, and returns the
+	// copies' paths
+	ResolveConflicts() ([]string, error)
 }
@@ -1,219 +1,391 @@
 package categorizer
 
 import (
+	"embed"
+	"fmt"
+	"path"
 	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"stak/internal/config"
 	"stak/internal/models"
 	"stak/internal/ports"
-	"strings"
 )
 
 // Compile-time check to ensure Categoriser implements CategorizerPort
 var _ ports.CategorizerPort = (*Categoriser)(nil)
 
-type Categoriser struct {
-	linkRegex     *regexp.Regexp
-	todoRegex     *regexp.Regexp
-	codeRegex     *regexp.Regexp
-	questionRegex *regexp.Regexp
-	meetingRegex  *regexp.Regexp
-	tomorrowRegex *regexp.Regexp
+//go:embed rules.yaml
+var defaultRulesYAML []byte
+
+// localeRules holds the keyword packs, one locales/<code>.yaml per language
+//
+//go:embed locales/*.yaml
+var localeRules embed.FS
+
+// rule is a compiled config.CategoryRule
+type rule struct {
+	name       string
+	entryType  models.EntryType
+	tags       []string
+	types      []models.EntryType
+	precedence int
+	// fallback rules match anything, so they only decide when nothing else does
+	fallback bool
+	// match reports whether the rule fires and the text that fired it
+	match func(doc *document) (string, bool)
 }
 
-func New() *Categoriser {
-	return &Categoriser{
-		linkRegex:     regexp.MustCompile(`https?://[^\s]+`),
-		todoRegex:     regexp.MustCompile(`(?i)^(\s*-\s*\[\s*\]\s*|todo:|\[\s*\]|\*\s+|•\s+|need to|should|must|have to|remember to|don't forget)`),
-		codeRegex:     regexp.MustCompile("```|`[^`]+`|\\$\\s+[a-zA-Z]|import\\s+|function\\s+|class\\s+|def\\s+|const\\s+|let\\s+|var\\s+"),
-		questionRegex: regexp.MustCompile(`\?(\s|$)`),
-		meetingRegex:  regexp.MustCompile(`(?i)(meeting|standup|sync|1:1|one-on-one|zoom|conference|call.*(meeting|scheduled|today|tomorrow))`),
-		tomorrowRegex: regexp.MustCompile(`(?i)\btomorrow\b`),
-	}
+// matches reports whether the rule fires for doc
+func (r rule) matches(doc *document) bool {
+	_, ok := r.match(doc)
+	return ok
 }
 
-func (c *Categoriser) CategoriseEntry(entry *models.Entry) {
-	content := strings.ToLower(entry.Content)
-	originalContent := entry.Content
-
-	switch {
-	case c.linkRegex.MatchString(originalContent):
-		entry.Type = models.TypeLink
-		if urls := c.linkRegex.FindAllString(originalContent, -1); len(urls) > 0 {
-			entry.URL = urls[0]
+// appliesTo reports whether a tag-only rule runs for entries of type t
+func (r rule) appliesTo(t models.EntryType) bool {
+	if len(r.types) == 0 {
+		return true
+	}
+	for _, allowed := range r.types {
+		if allowed == t {
+			return true
 		}
-		c.extractTags(entry, []string{"link", "web", "reference"})
+	}
+	return false
+}
 
-	case c.codeRegex.MatchString(originalContent):
-		entry.Type = models.TypeCode
-		c.extractCodeTags(entry, originalContent)
+type Categoriser struct {
+	typeRules []rule // highest precedence first
+	tagRules  []rule
+}
+
+// New returns a categoriser using only the shipped English rules
+func New() *Categoriser {
+	return NewWithConfig(config.CategoriesConfig{})
+}
 
-	case c.questionRegex.MatchString(originalContent):
-		entry.Type = models.TypeQuestion
-		c.extractTags(entry, []string{"question", "inquiry"})
-		c.extractLanguageTags(entry, originalContent)
+// NewWithConfig returns a categoriser using the shipped English rules merged
+// with the config's categories
+func NewWithConfig(categories config.CategoriesConfig) *Categoriser {
+	return NewWithLocales(nil, categories)
+}
 
-	case c.meetingRegex.MatchString(content):
-		entry.Type = models.TypeMeeting
-		c.extractTags(entry, []string{"meeting", "discussion"})
+// NewWithLocales returns a categoriser using the shipped rules with the
+// keyword packs for locales, English when none are given, merged with the
+// config's categories. Unknown locales add no keywords, and a disabled rule
+// or one whose regex does not compile is dropped.
+func NewWithLocales(locales []string, categories config.CategoriesConfig) *Categoriser {
+	shipped, _ := LocaleRules(locales)
+
+	c := &Categoriser{}
+	for _, cfg := range categories.Merge(shipped) {
+		if cfg.Disabled {
+			continue
+		}
+		r, err := compileRule(cfg)
+		if err != nil {
+			continue
+		}
+		if r.entryType != "" {
+			c.typeRules = append(c.typeRules, r)
+		} else {
+			c.tagRules = append(c.tagRules, r)
+		}
+	}
 
-	case c.isTodo(originalContent):
-		entry.Type = models.TypeTodo
-		entry.TodoStatus = models.TodoPending
-		c.extractTags(entry, []string{"todo", "task"})
+	sort.SliceStable(c.typeRules, func(i, j int) bool {
+		return c.typeRules[i].precedence > c.typeRules[j].precedence
+	})
+	return c
+}
 
+// ForConfig returns the categorizer chosen by cfg.Categorizer. A learning
+// categorizer starts from the model last saved; it is not trained here.
+func ForConfig(cfg *config.Config) ports.CategorizerPort {
+	rules := NewWithLocales(cfg.Locales, cfg.Categories)
+	switch cfg.Categorizer {
+	case "learning":
+		return NewLearning(rules, cfg.DataDir)
+	case "command":
+		return NewCommand(rules, cfg.CategorizerCommand)
 	default:
-		entry.Type = models.TypeNote
-		c.extractGeneralTags(entry, content)
+		return rules
 	}
+}
 
-	c.extractDomainTags(entry, content)
+// DefaultRules returns the shipped rule set with the English keywords
+func DefaultRules() []config.CategoryRule {
+	rules, _ := LocaleRules(nil)
+	return rules
 }
 
-func (c *Categoriser) isTodo(content string) bool {
-	// First check explicit todo patterns
-	if c.todoRegex.MatchString(content) {
-		return true
+// Locales lists the locales with a shipped keyword pack
+func Locales() []string {
+	files, _ := localeRules.ReadDir("locales")
+	var locales []string
+	for _, file := range files {
+		locales = append(locales, strings.TrimSuffix(file.Name(), ".yaml"))
 	}
+	return locales
+}
 
-	lowerContent := strings.ToLower(content)
-
-	// Check for action verbs that indicate todos
-	actionVerbs := []string{
-		"fix", "update", "implement", "create", "build", "add", "remove",
-		"refactor", "test", "deploy", "setup", "install", "configure",
-		"write", "read", "check", "review", "merge", "commit", "push",
-		"debug", "investigate", "research", "learn", "practice",
-		"buy", "call", "email", "schedule", "book", "contact",
-		"finish", "complete", "start", "begin", "continue",
-		"prepare", "plan", "organize", "clean", "backup", "sync",
-		"send", "reply", "respond", "follow", "track", "monitor",
+// LocaleRules returns the shipped language-neutral rules followed by the
+// keyword pack of each locale, English when none are given. Unknown
+// locales are reported after the known ones are loaded.
+func LocaleRules(locales []string) ([]config.CategoryRule, error) {
+	if len(locales) == 0 {
+		locales = []string{"en"}
 	}
 
-	// Check if it starts with an action verb
-	for _, verb := range actionVerbs {
-		if strings.HasPrefix(lowerContent, verb+" ") ||
-			strings.HasPrefix(lowerContent, verb+":") ||
-			lowerContent == verb {
-			return true
+	rules := parseRules("rules.yaml", defaultRulesYAML)
+	var unknown []string
+	for _, locale := range locales {
+		data, err := localeRules.ReadFile(path.Join("locales", locale+".yaml"))
+		if err != nil {
+			unknown = append(unknown, locale)
+			continue
 		}
+		rules = append(rules, parseRules(locale+".yaml", data)...)
 	}
 
-	// Check for todo indicators
-	todoIndicators := []string{
-		"need to", "should", "must", "have to", "remember to",
-		"don't forget", "todo:", "task:", "action:", "next:",
-		"tomorrow", "later", "work on", "get done",
-		"todo", "task", "action", "handle",
-		"later today", "this week", "before", "after",
+	if len(unknown) > 0 {
+		return rules, fmt.Errorf("unknown locales %s, use %s",
+			strings.Join(unknown, ", "), strings.Join(Locales(), ", "))
 	}
+	return rules, nil
+}
 
-	for _, indicator := range todoIndicators {
-		if strings.Contains(lowerContent, indicator) {
-			return true
-		}
+func parseRules(name string, data []byte) []config.CategoryRule {
+	var rules []config.CategoryRule
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		panic(fmt.Sprintf("invalid shipped categorization rules %s: %v", name, err))
 	}
-
-	return false
+	return rules
 }
 
-func (c *Categoriser) extractCodeTags(entry *models.Entry, content string) {
-	tags := []string{"code"}
+func compileRule(cfg config.CategoryRule) (rule, error) {
+	r := rule{
+		name:       cfg.Name,
+		entryType:  models.EntryType(cfg.Type),
+		tags:       cfg.Tags,
+		precedence: cfg.Precedence,
+	}
+	for _, t := range cfg.Types {
+		r.types = append(r.types, models.EntryType(t))
+	}
 
-	languages := map[string]string{
-		"go":         "golang",
-		"golang":     "golang",
-		"javascript": "js",
-		"typescript": "ts",
-		"python":     "python",
-		"rust":       "rust",
-		"java":       "java",
-		"docker":     "docker",
-		"sql":        "database",
-		"bash":       "shell",
-		"yaml":       "config",
-		"json":       "config",
+	fold := func(s string) string {
+		if cfg.CaseSensitive {
+			return s
+		}
+		return strings.ToLower(s)
+	}
+	patterns := make([]string, len(cfg.Patterns))
+	for i, p := range cfg.Patterns {
+		patterns[i] = fold(p)
 	}
 
-	contentLower := strings.ToLower(content)
-	for keyword, tag := range languages {
-		if strings.Contains(contentLower, keyword) {
-			tags = append(tags, tag)
+	switch cfg.Match {
+	case "any":
+		r.fallback = true
+		r.match = func(*document) (string, bool) { return "", true }
+
+	case "regex":
+		var regexes []*regexp.Regexp
+		for _, p := range cfg.Patterns {
+			if !cfg.CaseSensitive {
+				p = "(?i)" + p
+			}
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return rule{}, fmt.Errorf("rule %q: %w", cfg.Name, err)
+			}
+			regexes = append(regexes, re)
+		}
+		r.match = func(doc *document) (string, bool) {
+			for _, re := range regexes {
+				if loc := re.FindStringIndex(doc.text); loc != nil {
+					return doc.text[loc[0]:loc[1]], true
+				}
+			}
+			return "", false
+		}
+
+	case "prefix":
+		// The prefix must be a whole leading word: "fix bug" or "fix:" but
+		// not "fixture"
+		r.match = func(doc *document) (string, bool) {
+			text := fold(doc.text)
+			for _, p := range patterns {
+				if rest, ok := strings.CutPrefix(text, p); ok {
+					if rest == "" || rest[0] == ' ' || rest[0] == ':' {
+						return p, true
+					}
+				}
+			}
+			return "", false
+		}
+
+	case "word":
+		// Whole words or phrases, compared token by token so "go" does not
+		// match "good" and URLs are never searched
+		var phrases [][]string
+		for _, p := range cfg.Patterns {
+			phrases = append(phrases, tokenize(p))
+		}
+		r.match = func(doc *document) (string, bool) {
+			for _, phrase := range phrases {
+				if containsPhrase(doc.tokens, phrase) {
+					return strings.Join(phrase, " "), true
+				}
+			}
+			return "", false
+		}
+
+	case "keyword", "":
+		r.match = func(doc *document) (string, bool) {
+			text := fold(doc.text)
+			for _, p := range patterns {
+				if strings.Contains(text, p) {
+					return p, true
+				}
+			}
+			return "", false
 		}
+
+	default:
+		return rule{}, fmt.Errorf("rule %q: unknown match %q", cfg.Name, cfg.Match)
 	}
 
-	c.extractTags(entry, tags)
+	return r, nil
 }
 
-func (c *Categoriser) extractGeneralTags(entry *models.Entry, content string) {
-	keywords := map[string]string{
-		"idea":       "idea",
-		"brainstorm": "brainstorm",
-		"thought":    "reflection",
-		"note":       "note",
-		"reminder":   "reminder",
-		"important":  "important",
-		"urgent":     "urgent",
-		"bug":        "bug",
-		"feature":    "feature",
-		"fix":        "fix",
-	}
+func (c *Categoriser) CategoriseEntry(entry *models.Entry) models.Categorization {
+	return c.categoriseAs(entry, "")
+}
 
-	var tags []string
-	for keyword, tag := range keywords {
-		if strings.Contains(content, keyword) {
-			tags = append(tags, tag)
+// categoriseAs runs the rules over entry. With entryType set the type rules
+// do not decide the type; the first rule for entryType, preferably one that
+// matches, still supplies its tags. The explanation always describes what
+// the rules themselves would pick.
+func (c *Categoriser) categoriseAs(entry *models.Entry, entryType models.EntryType) models.Categorization {
+	text := entry.Content
+	doc := newDocument(text)
+	result := c.explain(doc)
+
+	// The first matching type rule decides the type
+	entry.Type = models.TypeNote
+	if entryType != "" {
+		entry.Type = entryType
+	}
+	var chosen *rule
+	for i, r := range c.typeRules {
+		if entryType != "" && r.entryType != entryType {
+			continue
+		}
+		if r.matches(doc) {
+			chosen = &c.typeRules[i]
+			break
+		}
+		if chosen == nil && entryType != "" {
+			chosen = &c.typeRules[i]
 		}
 	}
+	if chosen != nil {
+		entry.Type = chosen.entryType
+		c.extractTags(entry, chosen.tags)
+	}
 
-	// Also check for programming languages in all content
-	c.extractLanguageTags(entry, content)
+	if entry.Type == models.TypeTodo {
+		entry.TodoStatus = models.TodoPending
+	}
 
-	if len(tags) == 0 {
-		tags = []string{"note"}
+	// Every matching tag rule adds its tags
+	for _, r := range c.tagRules {
+		if r.appliesTo(entry.Type) && r.matches(doc) {
+			c.extractTags(entry, r.tags)
+		}
 	}
 
-	c.extractTags(entry, tags)
+	// Tags the user wrote explicitly come on top of the inferred ones
+	entry.ApplyInlineTokens()
+
+	return result
 }
 
-func (c *Categoriser) extractLanguageTags(entry *models.Entry, content string) {
-	languages := map[string]string{
-		"go":         "golang",
-		"golang":     "golang",
-		"javascript": "js",
-		"typescript": "ts",
-		"python":     "python",
-		"rust":       "rust",
-		"java":       "java",
-		"docker":     "docker",
-		"sql":        "database",
-		"bash":       "shell",
-		"yaml":       "config",
-		"json":       "config",
+// explain scores every type whose rules match doc. A type weighs its best
+// matching rule's precedence plus ten, and its confidence is its share of
+// the total weight, so a type nothing competes with is certain. When only a
+// fallback rule matches the guess is given even odds.
+func (c *Categoriser) explain(doc *document) models.Categorization {
+	weights := make(map[models.EntryType]float64)
+	var order []models.EntryType
+	var reasons []string
+	var fallback models.EntryType
+
+	for _, r := range c.typeRules {
+		matched, ok := r.match(doc)
+		if !ok {
+			continue
+		}
+		if r.fallback {
+			if fallback == "" {
+				fallback = r.entryType
+			}
+			continue
+		}
+		if _, seen := weights[r.entryType]; !seen {
+			order = append(order, r.entryType)
+			weights[r.entryType] = float64(r.precedence + 10)
+		}
+		if r.entryType == order[0] {
+			reasons = append(reasons, describeMatch(r.name, matched))
+		}
 	}
 
-	contentLower := strings.ToLower(content)
-	for keyword, tag := range languages {
-		if strings.Contains(contentLower, keyword) {
-			c.extractTags(entry, []string{tag})
+	if len(order) == 0 {
+		if fallback == "" {
+			fallback = models.TypeNote
+		}
+		return models.Categorization{
+			Type:       fallback,
+			Confidence: 0.5,
+			Reasons:    []string{"no rule matched"},
 		}
 	}
-}
 
-func (c *Categoriser) extractDomainTags(entry *models.Entry, content string) {
-	domains := map[string]string{
-		"work":     "work",
-		"personal": "personal",
-		"project":  "project",
-		"learning": "learning",
-		"research": "research",
-		"client":   "client",
-		"team":     "team",
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	result := models.Categorization{
+		Type:       order[0],
+		Confidence: weights[order[0]] / total,
+		Reasons:    reasons,
+	}
+	for _, t := range order[1:] {
+		result.RunnersUp = append(result.RunnersUp, models.TypeScore{Type: t, Confidence: weights[t] / total})
+	}
+	if fallback != "" && fallback != result.Type && weights[fallback] == 0 {
+		result.RunnersUp = append(result.RunnersUp, models.TypeScore{Type: fallback})
 	}
+	return result
+}
 
-	for keyword, tag := range domains {
-		if strings.Contains(content, keyword) {
-			entry.Tags = append(entry.Tags, tag)
-		}
+// describeMatch says which rule fired and on what text
+func describeMatch(name, matched string) string {
+	matched = strings.Join(strings.Fields(matched), " ")
+	matched = strings.ReplaceAll(matched, ";", ",")
+	if matched == "" {
+		return fmt.Sprintf("rule %s", name)
+	}
+	if runes := []rune(matched); len(runes) > 30 {
+		matched = string(runes[:29]) + "…"
 	}
+	return fmt.Sprintf("rule %s matched %q", name, matched)
 }
 
 func (c *Categoriser) extractTags(entry *models.Entry, tags []string) {
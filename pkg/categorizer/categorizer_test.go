@@ -1,8 +1,10 @@
 package categorizer
 
 import (
+	"strings"
 	"testing"
 
+	"stak/internal/config"
 	"stak/internal/models"
 )
 
@@ -14,6 +16,7 @@ func TestCategoriseEntry(t *testing.T) {
 		content        string
 		expectedType   models.EntryType
 		expectedTags   []string
+		unexpectedTags []string
 		expectedStatus models.TodoStatus
 	}{
 		{
@@ -22,6 +25,18 @@ func TestCategoriseEntry(t *testing.T) {
 			expectedType: models.TypeLink,
 			expectedTags: []string{"link", "web", "reference"},
 		},
+		{
+			name:         "Idea prefix should be categorised as idea",
+			content:      "Idea: a CLI that tracks houseplants",
+			expectedType: models.TypeIdea,
+			expectedTags: []string{"idea"},
+		},
+		{
+			name:         "What if question should be categorised as idea",
+			content:      "what if we cached the search index?",
+			expectedType: models.TypeIdea,
+			expectedTags: []string{"idea"},
+		},
 		{
 			name:           "Todo with checkbox should be categorised as todo",
 			content:        "- [ ] Fix authentication bug",
@@ -95,6 +110,55 @@ func TestCategoriseEntry(t *testing.T) {
 			expectedType: models.TypeNote,
 			expectedTags: []string{"note"},
 		},
+		{
+			name:           "Words starting with go are not golang",
+			content:        "This looks good, going with it",
+			expectedType:   models.TypeNote,
+			unexpectedTags: []string{"golang"},
+		},
+		{
+			name:           "Javascript is not java",
+			content:        "Closures in javascript are neat",
+			expectedType:   models.TypeNote,
+			expectedTags:   []string{"js"},
+			unexpectedTags: []string{"java"},
+		},
+		{
+			name:           "Teammate is not team",
+			content:        "Lunch with a teammate at the workshop",
+			expectedType:   models.TypeNote,
+			unexpectedTags: []string{"team", "work"},
+		},
+		{
+			name:         "Keywords are matched through punctuation",
+			content:      "Notes on Rust, Docker & YAML (config)",
+			expectedType: models.TypeNote,
+			expectedTags: []string{"rust", "docker", "config"},
+		},
+		{
+			name:           "URLs are not searched for keywords",
+			content:        "Read later https://golang.org/team/personal",
+			expectedType:   models.TypeLink,
+			unexpectedTags: []string{"golang", "team", "personal"},
+		},
+		{
+			name:         "Code fence language is a keyword",
+			content:      "```python\nprint(1)\n```",
+			expectedType: models.TypeCode,
+			expectedTags: []string{"code", "python"},
+		},
+		{
+			name:           "Async is not a sync meeting",
+			content:        "Async processing is faster",
+			expectedType:   models.TypeNote,
+			unexpectedTags: []string{"meeting"},
+		},
+		{
+			name:           "Keywords inside words do not make code",
+			content:        "The redefinition was important",
+			expectedType:   models.TypeNote,
+			unexpectedTags: []string{"code"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -122,6 +186,308 @@ func TestCategoriseEntry(t *testing.T) {
 					t.Errorf("expected tag %v not found in tags %v", expectedTag, entry.Tags)
 				}
 			}
+
+			for _, unexpectedTag := range tt.unexpectedTags {
+				if contains(entry.Tags, unexpectedTag) {
+					t.Errorf("unexpected tag %v in tags %v", unexpectedTag, entry.Tags)
+				}
+			}
+		})
+	}
+}
+
+func TestInlineTokens(t *testing.T) {
+	categoriser := New()
+
+	tests := []struct {
+		name     string
+		content  string
+		tags     []string
+		people   []string
+		projects []string
+	}{
+		{
+			name:     "Tag, person and project",
+			content:  "Ask @Sam about the #Release plan for +stak",
+			tags:     []string{"release"},
+			people:   []string{"sam"},
+			projects: []string{"stak"},
+		},
+		{
+			name:     "Trailing punctuation is not part of the token",
+			content:  "Pair with @alice, then ship +web-app.",
+			people:   []string{"alice"},
+			projects: []string{"web-app"},
+		},
+		{
+			name:    "Emails, URLs, issue numbers and headings are not tokens",
+			content: "Mail bob@example.com about https://example.com/#section and #42\n# Heading",
+		},
+		{
+			name:    "Code spans are skipped",
+			content: "Run `git log +feature @HEAD #x` now",
+		},
+		{
+			name:     "Repeated tokens are kept once",
+			content:  "#ops #OPS +infra +infra",
+			tags:     []string{"ops"},
+			projects: []string{"infra"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := models.NewEntry(tt.content)
+			categoriser.CategoriseEntry(entry)
+
+			for _, tag := range tt.tags {
+				if !contains(entry.Tags, tag) {
+					t.Errorf("expected tag %q in %v", tag, entry.Tags)
+				}
+			}
+			if strings.Join(entry.People, ",") != strings.Join(tt.people, ",") {
+				t.Errorf("expected people %v, got %v", tt.people, entry.People)
+			}
+			if strings.Join(entry.Projects, ",") != strings.Join(tt.projects, ",") {
+				t.Errorf("expected projects %v, got %v", tt.projects, entry.Projects)
+			}
+			if len(tt.tags) == 0 && (contains(entry.Tags, "section") || contains(entry.Tags, "x")) {
+				t.Errorf("expected no inline tags, got %v", entry.Tags)
+			}
+		})
+	}
+}
+
+func TestCategorisationExplained(t *testing.T) {
+	categoriser := New()
+
+	entry := models.NewEntry("team standup tomorrow")
+	result := categoriser.CategoriseEntry(entry)
+	if result.Type != models.TypeMeeting || entry.Type != models.TypeMeeting {
+		t.Fatalf("expected a meeting, got %s (entry %s)", result.Type, entry.Type)
+	}
+	if result.Confidence <= 0.5 || result.Confidence >= 1 {
+		t.Errorf("expected a contested but likely meeting, got confidence %.2f", result.Confidence)
+	}
+	if len(result.Reasons) == 0 || result.Reasons[0] != `rule meeting matched "standup"` {
+		t.Errorf("expected the meeting rule as the reason, got %v", result.Reasons)
+	}
+	if len(result.RunnersUp) == 0 || result.RunnersUp[0].Type != models.TypeTodo {
+		t.Errorf("expected todo as the runner-up, got %v", result.RunnersUp)
+	}
+
+	plain := models.NewEntry("quiet afternoon")
+	result = categoriser.CategoriseEntry(plain)
+	if result.Type != models.TypeNote || result.Confidence != 0.5 || result.Reasons[0] != "no rule matched" {
+		t.Errorf("expected an unsure note, got %+v", result)
+	}
+
+	// The explanation survives a trip through the entry's metadata
+	entry.SetCategorization(categoriser.CategoriseEntry(entry))
+	stored, ok := entry.Categorization()
+	if !ok || stored.Type != models.TypeMeeting || len(stored.Reasons) == 0 || len(stored.RunnersUp) == 0 {
+		t.Errorf("expected the explanation to be stored, got %+v", stored)
+	}
+}
+
+func TestDefaultRulesCompile(t *testing.T) {
+	rules := DefaultRules()
+	if err := (config.CategoriesConfig{Rules: rules}).Validate(); err != nil {
+		t.Fatalf("shipped rules are invalid: %v", err)
+	}
+
+	c := New()
+	if got := len(c.typeRules) + len(c.tagRules); got != len(rules) {
+		t.Errorf("expected all %d shipped rules to compile, got %d", len(rules), got)
+	}
+}
+
+func TestLocaleRules(t *testing.T) {
+	tests := []struct {
+		locale       string
+		content      string
+		expectedType models.EntryType
+	}{
+		{"es", "Arreglar la puerta del garaje", models.TypeTodo},
+		{"es", "tengo que llamar al banco", models.TypeTodo},
+		{"es", "Mañana el informe de ventas", models.TypeTodo},
+		{"es", "Reunión con el equipo de diseño", models.TypeMeeting},
+		{"es", "Y si compartimos la caché entre equipos", models.TypeIdea},
+		{"de", "Milch kaufen", models.TypeTodo},
+		{"de", "Nicht vergessen: Steuererklärung", models.TypeTodo},
+		{"de", "Übermorgen den Bericht abgeben", models.TypeTodo},
+		{"de", "Besprechung mit dem Vertrieb", models.TypeMeeting},
+		{"de", "Was wäre wenn wir den Cache teilen", models.TypeIdea},
+		{"fr", "Acheter du pain", models.TypeTodo},
+		{"fr", "Il faut relancer le fournisseur", models.TypeTodo},
+		{"fr", "Demain le rapport trimestriel", models.TypeTodo},
+		{"fr", "Réunion avec l'équipe produit", models.TypeMeeting},
+		{"fr", "Et si on partageait le cache", models.TypeIdea},
+		{"pt", "Comprar pão e leite", models.TypeTodo},
+		{"pt", "Não esquecer o relatório", models.TypeTodo},
+		{"pt", "Amanhã o relatório mensal", models.TypeTodo},
+		{"pt", "Reunião com o cliente", models.TypeMeeting},
+		{"pt", "Ideia: um app para regar as plantas", models.TypeIdea},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+"/"+tt.content, func(t *testing.T) {
+			entry := &models.Entry{Content: tt.content}
+			NewWithLocales([]string{tt.locale}, config.CategoriesConfig{}).CategoriseEntry(entry)
+			if entry.Type != tt.expectedType {
+				t.Errorf("expected %s with the %s pack, got %s", tt.expectedType, tt.locale, entry.Type)
+			}
+
+			entry = &models.Entry{Content: tt.content}
+			New().CategoriseEntry(entry)
+			if entry.Type != models.TypeNote {
+				t.Errorf("expected a note without the %s pack, got %s", tt.locale, entry.Type)
+			}
+		})
+	}
+}
+
+func TestLocales(t *testing.T) {
+	for _, locale := range Locales() {
+		rules, err := LocaleRules([]string{locale})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := (config.CategoriesConfig{Rules: rules}).Validate(); err != nil {
+			t.Errorf("%s pack is invalid: %v", locale, err)
+		}
+	}
+
+	// Several packs can be active at once
+	c := NewWithLocales([]string{"en", "es"}, config.CategoriesConfig{})
+	for _, content := range []string{"Fix the login page", "Arreglar la página de inicio"} {
+		entry := &models.Entry{Content: content}
+		c.CategoriseEntry(entry)
+		if entry.Type != models.TypeTodo {
+			t.Errorf("expected %q to be a todo with en and es, got %s", content, entry.Type)
+		}
+	}
+
+	if _, err := LocaleRules([]string{"xx"}); err == nil {
+		t.Error("expected an unknown locale to be reported")
+	}
+}
+
+func TestConfiguredRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		categories   config.CategoriesConfig
+		content      string
+		expectedType models.EntryType
+		expectedTags []string
+		absentTags   []string
+	}{
+		{
+			name: "Disabled rule no longer matches",
+			categories: config.CategoriesConfig{Rules: []config.CategoryRule{
+				{Name: "todo-indicators", Disabled: true},
+			}},
+			content:      "The release went out before lunch",
+			expectedType: models.TypeNote,
+		},
+		{
+			name: "Override changes only the fields it sets",
+			categories: config.CategoriesConfig{Rules: []config.CategoryRule{
+				{Name: "todo-indicators", Patterns: []string{"remember to"}},
+			}},
+			content:      "Remember to water the plants",
+			expectedType: models.TypeTodo,
+			expectedTags: []string{"todo", "task"},
+		},
+		{
+			name: "New rule with higher precedence wins",
+			categories: config.CategoriesConfig{Rules: []config.CategoryRule{
+				{Name: "ideas", Type: "idea", Match: "prefix", Patterns: []string{"idea"}, Precedence: 70, Tags: []string{"idea"}},
+			}},
+			content:      "idea: a CLI for https://example.com",
+			expectedType: models.TypeIdea,
+			expectedTags: []string{"idea"},
+			absentTags:   []string{"link"},
+		},
+		{
+			name: "New tag rule applies to every type",
+			categories: config.CategoriesConfig{Rules: []config.CategoryRule{
+				{Name: "k8s", Match: "word", Patterns: []string{"kubectl", "k8s"}, Tags: []string{"kubernetes"}},
+			}},
+			content:      "Check the k8s dashboard",
+			expectedType: models.TypeTodo,
+			expectedTags: []string{"kubernetes"},
+		},
+		{
+			name: "Replacing the defaults leaves only the configured rules",
+			categories: config.CategoriesConfig{ReplaceDefaults: true, Rules: []config.CategoryRule{
+				{Name: "bugs", Type: "todo", Match: "regex", Patterns: []string{`^bug:`}, Tags: []string{"bug"}},
+			}},
+			content:      "Need to fix the golang build",
+			expectedType: models.TypeNote,
+			absentTags:   []string{"todo", "golang", "note"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.categories.Validate(); err != nil {
+				t.Fatalf("invalid test config: %v", err)
+			}
+			entry := models.NewEntry(tt.content)
+			NewWithConfig(tt.categories).CategoriseEntry(entry)
+
+			if entry.Type != tt.expectedType {
+				t.Errorf("expected type %v, got %v", tt.expectedType, entry.Type)
+			}
+			for _, tag := range tt.expectedTags {
+				if !contains(entry.Tags, tag) {
+					t.Errorf("expected tag %q in %v", tag, entry.Tags)
+				}
+			}
+			for _, tag := range tt.absentTags {
+				if contains(entry.Tags, tag) {
+					t.Errorf("expected no tag %q in %v", tag, entry.Tags)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidRulesAreRejected(t *testing.T) {
+	tests := []config.CategoryRule{
+		{Type: "todo", Match: "keyword"},
+		{Name: "bad-match", Match: "fuzzy"},
+		{Name: "bad-type", Type: "chore"},
+		{Name: "bad-regex", Match: "regex", Patterns: []string{"("}},
+	}
+
+	for _, r := range tests {
+		if err := (config.CategoriesConfig{Rules: []config.CategoryRule{r}}).Validate(); err == nil {
+			t.Errorf("expected rule %+v to be rejected", r)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"don't forget C++ and C#", []string{"don't", "forget", "c++", "and", "c#"}},
+		{"node.js vs go-kit", []string{"node", "js", "vs", "go", "kit"}},
+		{"see https://go.dev/doc and www.example.com/x", []string{"see", "and"}},
+		{"```go\nfmt.Println()\n```", []string{"go", "fmt", "println"}},
+		{"#ops @sam +infra", []string{"ops", "sam", "infra"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := tokenize(tt.text)
+			if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
 		})
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,179 @@
+package categorizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"stak/internal/config"
+	"stak/internal/models"
+	"stak/internal/ports"
+)
+
+// Compile-time check to ensure CommandCategoriser implements CategorizerPort
+var _ ports.CategorizerPort = (*CommandCategoriser)(nil)
+
+// defaultCommandTimeout bounds a categorizer command with no timeout set
+const defaultCommandTimeout = 2 * time.Second
+
+// CommandResult is what a categorizer command prints on stdout. Only Type
+// is required; Tags replace the rules' tags and Metadata is merged into the
+// entry's, leaving out the keys stak keeps itself.
+type CommandResult struct {
+	Type       string            `json:"type"`
+	Tags       []string          `json:"tags,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Confidence float64           `json:"confidence,omitempty"`
+	Reasons    []string          `json:"reasons,omitempty"`
+}
+
+// CommandCategoriser hands entries to an external executable, so teams can
+// plug in their own classification without changing stak. The entry is
+// written to the command's stdin as JSON and a CommandResult is read from
+// its stdout. When the command fails, times out or answers with something
+// unusable, the rules categorise the entry and the failure is recorded in
+// the explanation.
+type CommandCategoriser struct {
+	rules   *Categoriser
+	path    string
+	args    []string
+	timeout time.Duration
+}
+
+// NewCommand returns a categoriser running cmd, falling back to rules
+func NewCommand(rules *Categoriser, cmd config.CategorizerCommand) *CommandCategoriser {
+	timeout := cmd.Timeout
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+	path := cmd.Path
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return &CommandCategoriser{
+		rules:   rules,
+		path:    path,
+		args:    cmd.Args,
+		timeout: timeout,
+	}
+}
+
+func (c *CommandCategoriser) CategoriseEntry(entry *models.Entry) models.Categorization {
+	result, err := c.run(*entry)
+	if err == nil {
+		var entryType models.EntryType
+		entryType, err = c.parseType(result.Type)
+		if err == nil {
+			return c.apply(entry, entryType, result)
+		}
+	}
+
+	fallback := c.rules.CategoriseEntry(entry)
+	reason := fmt.Sprintf("categorizer command failed (%v), used the rules", err)
+	fallback.Reasons = append(fallback.Reasons, strings.ReplaceAll(reason, ";", ","))
+	return fallback
+}
+
+// run sends entry to the command and decodes its answer
+func (c *CommandCategoriser) run(entry models.Entry) (CommandResult, error) {
+	input, err := json.Marshal(entry)
+	if err != nil {
+		return CommandResult{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.path, c.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait on pipes held open by anything the command left running
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return CommandResult{}, fmt.Errorf("timed out after %s", c.timeout)
+		}
+		if message := firstLine(stderr.String()); message != "" {
+			return CommandResult{}, fmt.Errorf("%w: %s", err, message)
+		}
+		return CommandResult{}, err
+	}
+
+	var result CommandResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return CommandResult{}, fmt.Errorf("invalid output: %w", err)
+	}
+	return result, nil
+}
+
+func (c *CommandCategoriser) parseType(name string) (models.EntryType, error) {
+	if name == "" {
+		return "", errors.New("no type in output")
+	}
+	entryType, ok := models.ParseEntryType(name)
+	if !ok {
+		return "", fmt.Errorf("unknown type %q", name)
+	}
+	return entryType, nil
+}
+
+// apply gives entry the command's answer, filling in the todo status like
+// the rules would
+func (c *CommandCategoriser) apply(entry *models.Entry, entryType models.EntryType, result CommandResult) models.Categorization {
+	entry.Type = entryType
+	entry.Tags = []string{}
+	c.rules.extractTags(entry, result.Tags)
+	if entryType == models.TypeTodo {
+		entry.TodoStatus = models.TodoPending
+	}
+	entry.ApplyInlineTokens()
+
+	if len(result.Metadata) > 0 && entry.Metadata == nil {
+		entry.Metadata = make(map[string]string)
+	}
+	var ignored []string
+	for key, value := range result.Metadata {
+		if models.ReservedMetadataKey(key) {
+			ignored = append(ignored, key)
+			continue
+		}
+		entry.Metadata[key] = value
+	}
+
+	confidence := result.Confidence
+	if confidence <= 0 || confidence > 1 {
+		confidence = 1
+	}
+	reasons := []string{fmt.Sprintf("categorizer command %s", filepath.Base(c.path))}
+	for _, reason := range result.Reasons {
+		reasons = append(reasons, strings.ReplaceAll(reason, ";", ","))
+	}
+	if len(ignored) > 0 {
+		sort.Strings(ignored)
+		reasons = append(reasons, fmt.Sprintf("ignored reserved metadata %s", strings.Join(ignored, " ")))
+	}
+	return models.Categorization{Type: entryType, Confidence: confidence, Reasons: reasons}
+}
+
+// firstLine returns the first non-empty line of text, trimmed
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package categorizer
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"stak/internal/config"
+	"stak/internal/models"
+)
+
+// buildPlugin compiles the reference plugin in examples/categorizer-plugin
+func buildPlugin(t *testing.T) string {
+	t.Helper()
+
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available to build the reference plugin")
+	}
+	bin := filepath.Join(t.TempDir(), "categorizer-plugin")
+	build := exec.Command(goTool, "build", "-o", bin, "../../examples/categorizer-plugin")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("building the reference plugin: %v\n%s", err, out)
+	}
+	return bin
+}
+
+func TestCommandCategoriser(t *testing.T) {
+	bin := buildPlugin(t)
+	plugin := NewCommand(New(), config.CategorizerCommand{Path: bin, Timeout: 30 * time.Second})
+	// Only the hanging plugin should wait out its timeout
+	hasty := NewCommand(New(), config.CategorizerCommand{Path: bin, Timeout: 500 * time.Millisecond})
+
+	entry := models.NewEntry("write up the database outage #postmortem")
+	result := plugin.CategoriseEntry(entry)
+	if entry.Type != models.TypeTodo || entry.TodoStatus != models.TodoPending {
+		t.Errorf("expected the plugin's pending todo, got %s %q", entry.Type, entry.TodoStatus)
+	}
+	for _, tag := range []string{"ops", "todo", "postmortem"} {
+		if !contains(entry.Tags, tag) {
+			t.Errorf("expected tag %q from the plugin or the text, got %v", tag, entry.Tags)
+		}
+	}
+	if entry.Metadata["team"] != "ops" {
+		t.Errorf("expected the plugin's metadata, got %v", entry.Metadata)
+	}
+	if result.Confidence != 0.9 || len(result.Reasons) != 2 || result.Reasons[1] != `mentions "outage"` {
+		t.Errorf("expected the plugin's explanation, got %+v", result)
+	}
+
+	failures := []struct {
+		plugin  *CommandCategoriser
+		content string
+		reason  string
+	}{
+		{plugin, "standup notes plugin:fail", "exit status 3: asked to fail"},
+		{hasty, "standup notes plugin:hang", "timed out after 500ms"},
+		{plugin, "standup notes plugin:junk", "invalid output"},
+	}
+	for _, tt := range failures {
+		entry := models.NewEntry(tt.content)
+		result := tt.plugin.CategoriseEntry(entry)
+		if entry.Type != models.TypeMeeting {
+			t.Errorf("%q: expected the rules' meeting, got %s", tt.content, entry.Type)
+		}
+		last := result.Reasons[len(result.Reasons)-1]
+		if !strings.Contains(last, tt.reason) || !strings.HasSuffix(last, "used the rules") {
+			t.Errorf("%q: expected the failure %q to be explained, got %q", tt.content, tt.reason, last)
+		}
+	}
+}
+
+func TestCommandReservedMetadata(t *testing.T) {
+	plugin := NewCommand(New(), config.CategorizerCommand{Path: "plugin"})
+
+	entry := models.NewEntry("standup notes")
+	result := plugin.apply(entry, models.TypeNote, CommandResult{
+		Type: "note",
+		Metadata: map[string]string{
+			"team":           "ops",
+			"categorized_by": "user",
+			"tags_by":        "user",
+		},
+	})
+	if entry.CategorizedByHand() || entry.TagsEditedByHand() {
+		t.Errorf("expected the reserved keys to be left out, got %v", entry.Metadata)
+	}
+	if entry.Metadata["team"] != "ops" {
+		t.Errorf("expected the other metadata to be kept, got %v", entry.Metadata)
+	}
+	if last := result.Reasons[len(result.Reasons)-1]; last != "ignored reserved metadata categorized_by tags_by" {
+		t.Errorf("expected the ignored keys to be explained, got %q", last)
+	}
+}
+
+func TestCommandCategoriserMissing(t *testing.T) {
+	plugin := NewCommand(New(), config.CategorizerCommand{Path: filepath.Join(t.TempDir(), "missing")})
+
+	entry := models.NewEntry("need to water the plants")
+	result := plugin.CategoriseEntry(entry)
+	if entry.Type != models.TypeTodo {
+		t.Errorf("expected the rules' todo, got %s", entry.Type)
+	}
+	if last := result.Reasons[len(result.Reasons)-1]; !strings.HasPrefix(last, "categorizer command failed") {
+		t.Errorf("expected the missing command to be explained, got %q", last)
+	}
+}
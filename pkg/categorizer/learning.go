@@ -0,0 +1,317 @@
+package categorizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"stak/internal/models"
+	"stak/internal/ports"
+	"stak/pkg/storage"
+)
+
+// Compile-time check to ensure LearningCategoriser implements the ports
+var (
+	_ ports.CategorizerPort     = (*LearningCategoriser)(nil)
+	_ ports.CategoryLearnerPort = (*LearningCategoriser)(nil)
+)
+
+const (
+	// modelFileName is the learned model, kept in the data directory
+	modelFileName = ".stak-model.json"
+	// minTrainingEntries is how many examples the model needs before its
+	// guesses replace the rules
+	minTrainingEntries = 30
+	// minConfidence is the lowest probability the model's best type may
+	// have for it to be used over the rules
+	minConfidence = 0.6
+)
+
+// bayesModel is a multinomial naive Bayes classifier over entry tokens
+type bayesModel struct {
+	// Docs counts the training entries of each type
+	Docs map[models.EntryType]int `json:"docs"`
+	// Words counts each token's occurrences per type
+	Words map[models.EntryType]map[string]int `json:"words"`
+	// Totals is the sum of Words per type
+	Totals map[models.EntryType]int `json:"totals"`
+	// Learned maps entry IDs to the example they were trained as, so a
+	// later correction replaces the earlier example instead of adding to it
+	Learned map[string]learnedExample `json:"learned"`
+}
+
+// learnedExample is the type an entry was trained as and the tokens it had
+// then, which are what a correction has to take back out
+type learnedExample struct {
+	Type   models.EntryType `json:"type"`
+	Tokens []string         `json:"tokens"`
+}
+
+func newBayesModel() *bayesModel {
+	return &bayesModel{
+		Docs:    make(map[models.EntryType]int),
+		Words:   make(map[models.EntryType]map[string]int),
+		Totals:  make(map[models.EntryType]int),
+		Learned: make(map[string]learnedExample),
+	}
+}
+
+// features are the tokens of an entry plus markers for the things the
+// tokenizer drops but that say a lot about the type
+func features(content string) []string {
+	tokens := tokenize(content)
+	if models.LinkRegex.MatchString(content) {
+		tokens = append(tokens, "__url__")
+	}
+	if strings.Contains(content, "?") {
+		tokens = append(tokens, "__question__")
+	}
+	if strings.Contains(content, "`") {
+		tokens = append(tokens, "__code__")
+	}
+	if strings.HasPrefix(strings.TrimSpace(content), "- [") {
+		tokens = append(tokens, "__checkbox__")
+	}
+	return tokens
+}
+
+func (m *bayesModel) add(entryType models.EntryType, tokens []string, delta int) {
+	m.Docs[entryType] = max(m.Docs[entryType]+delta, 0)
+	if m.Words[entryType] == nil {
+		m.Words[entryType] = make(map[string]int)
+	}
+	for _, token := range tokens {
+		count := max(m.Words[entryType][token]+delta, 0)
+		if count == 0 {
+			delete(m.Words[entryType], token)
+		} else {
+			m.Words[entryType][token] = count
+		}
+		m.Totals[entryType] = max(m.Totals[entryType]+delta, 0)
+	}
+}
+
+// train adds entry as an example of its type, replacing any earlier
+// example for the same entry
+func (m *bayesModel) train(entry models.Entry) {
+	if previous, ok := m.Learned[entry.ID]; ok {
+		m.add(previous.Type, previous.Tokens, -1)
+	}
+	tokens := features(entry.Content)
+	m.add(entry.Type, tokens, 1)
+	m.Learned[entry.ID] = learnedExample{Type: entry.Type, Tokens: tokens}
+}
+
+func (m *bayesModel) size() int {
+	return len(m.Learned)
+}
+
+// typeScore is a type and the probability the model gives it
+type typeScore struct {
+	entryType   models.EntryType
+	probability float64
+}
+
+// classify ranks every known type for content, most likely first
+func (m *bayesModel) classify(content string) []typeScore {
+	tokens := features(content)
+	vocabulary := make(map[string]bool)
+	for _, words := range m.Words {
+		for word := range words {
+			vocabulary[word] = true
+		}
+	}
+
+	var scores []typeScore
+	total := m.size()
+	for entryType, docs := range m.Docs {
+		if docs == 0 {
+			continue
+		}
+		// Log probabilities with add-one smoothing
+		score := math.Log(float64(docs) / float64(total))
+		denominator := float64(m.Totals[entryType] + len(vocabulary))
+		for _, token := range tokens {
+			score += math.Log(float64(m.Words[entryType][token]+1) / denominator)
+		}
+		scores = append(scores, typeScore{entryType, score})
+	}
+	if len(scores) == 0 {
+		return nil
+	}
+
+	// Turn log scores into probabilities that sum to one
+	best := scores[0].probability
+	for _, s := range scores {
+		best = math.Max(best, s.probability)
+	}
+	var sum float64
+	for i := range scores {
+		scores[i].probability = math.Exp(scores[i].probability - best)
+		sum += scores[i].probability
+	}
+	for i := range scores {
+		scores[i].probability /= sum
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].probability != scores[j].probability {
+			return scores[i].probability > scores[j].probability
+		}
+		return scores[i].entryType < scores[j].entryType
+	})
+	return scores
+}
+
+// evidence returns up to three of content's words that most favour winner
+// over runnerUp, quoted
+func (m *bayesModel) evidence(content string, winner, runnerUp models.EntryType) []string {
+	type weighted struct {
+		word  string
+		ratio float64
+	}
+	var words []weighted
+	seen := make(map[string]bool)
+	for _, token := range tokenize(content) {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+		favour := float64(m.Words[winner][token]+1) / float64(m.Totals[winner]+1)
+		against := float64(m.Words[runnerUp][token]+1) / float64(m.Totals[runnerUp]+1)
+		if ratio := favour / against; ratio > 1.5 {
+			words = append(words, weighted{token, ratio})
+		}
+	}
+
+	sort.SliceStable(words, func(i, j int) bool { return words[i].ratio > words[j].ratio })
+	var quoted []string
+	for i := 0; i < len(words) && i < 3; i++ {
+		quoted = append(quoted, fmt.Sprintf("%q", words[i].word))
+	}
+	return quoted
+}
+
+// LearningCategoriser picks entry types with a naive Bayes model trained
+// on the stored entries and on the user's corrections. Tags still come
+// from the rules, and the rules decide alone until the model has seen
+// enough entries or whenever it is unsure.
+type LearningCategoriser struct {
+	rules *Categoriser
+	path  string
+
+	mu    sync.Mutex
+	model *bayesModel
+}
+
+// NewLearning loads the model saved in dataDir, starting empty if there
+// is none
+func NewLearning(rules *Categoriser, dataDir string) *LearningCategoriser {
+	l := &LearningCategoriser{
+		rules: rules,
+		path:  filepath.Join(dataDir, modelFileName),
+		model: newBayesModel(),
+	}
+
+	if data, err := os.ReadFile(l.path); err == nil {
+		model := newBayesModel()
+		if err := json.Unmarshal(data, model); err == nil {
+			l.model = model
+		}
+	}
+	return l
+}
+
+// Train learns every entry the model has not seen with its current type,
+// and saves the model if anything changed
+func (l *LearningCategoriser) Train(entries []models.Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	changed := false
+	for _, entry := range entries {
+		if entry.Type == "" || l.model.Learned[entry.ID].Type == entry.Type {
+			continue
+		}
+		l.model.train(entry)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return l.save()
+}
+
+// Learn records entry's current type as the right answer for its content
+func (l *LearningCategoriser) Learn(entry models.Entry) error {
+	if entry.Type == "" {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.model.train(entry)
+	return l.save()
+}
+
+func (l *LearningCategoriser) CategoriseEntry(entry *models.Entry) models.Categorization {
+	l.mu.Lock()
+	size := l.model.size()
+	var scores []typeScore
+	var evidence []string
+	if size >= minTrainingEntries && len(l.model.Docs) > 1 {
+		scores = l.model.classify(entry.Content)
+		if len(scores) > 1 {
+			evidence = l.model.evidence(entry.Content, scores[0].entryType, scores[1].entryType)
+		}
+	}
+	l.mu.Unlock()
+
+	switch {
+	case len(scores) == 0:
+		result := l.rules.CategoriseEntry(entry)
+		result.Reasons = append(result.Reasons,
+			fmt.Sprintf("learning model has seen %d of %d entries, used the rules", size, minTrainingEntries))
+		return result
+	case scores[0].probability < minConfidence:
+		result := l.rules.CategoriseEntry(entry)
+		result.Reasons = append(result.Reasons,
+			fmt.Sprintf("learning model unsure (%.0f%% %s), used the rules", 100*scores[0].probability, scores[0].entryType))
+		return result
+	}
+
+	l.rules.categoriseAs(entry, scores[0].entryType)
+	result := models.Categorization{
+		Type:       scores[0].entryType,
+		Confidence: scores[0].probability,
+		Reasons:    []string{fmt.Sprintf("learned from %d entries", size)},
+	}
+	if len(evidence) > 0 {
+		result.Reasons = append(result.Reasons, fmt.Sprintf("words %s", strings.Join(evidence, ", ")))
+	}
+	for _, s := range scores[1:] {
+		result.RunnersUp = append(result.RunnersUp, models.TypeScore{Type: s.entryType, Confidence: s.probability})
+	}
+	return result
+}
+
+// save writes the model next to the day files, replacing the old one in a
+// single rename
+func (l *LearningCategoriser) save() error {
+	data, err := json.Marshal(l.model)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := storage.LockDataDir(filepath.Dir(l.path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return storage.WriteFileAtomic(l.path, data, 0644)
+}
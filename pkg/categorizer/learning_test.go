@@ -0,0 +1,113 @@
+package categorizer
+
+import (
+	"fmt"
+	"testing"
+
+	"stak/internal/models"
+)
+
+// trainingEntries returns notes about groceries that the user filed as
+// todos, and notes about books they left as notes
+func trainingEntries() []models.Entry {
+	var entries []models.Entry
+	groceries := []string{"milk", "eggs", "bread", "butter", "apples", "coffee", "rice", "beans", "pasta", "onions"}
+	books := []string{"dune", "emma", "ulysses", "beloved", "middlemarch", "rebecca", "persuasion", "hamlet", "walden", "candide"}
+	for i := range groceries {
+		entries = append(entries,
+			models.Entry{ID: fmt.Sprintf("g%d", i), Type: models.TypeTodo, Content: "groceries " + groceries[i]},
+			models.Entry{ID: fmt.Sprintf("h%d", i), Type: models.TypeTodo, Content: "groceries " + groceries[i] + " and " + groceries[(i+1)%10]},
+			models.Entry{ID: fmt.Sprintf("b%d", i), Type: models.TypeNote, Content: "finished reading " + books[i]},
+			models.Entry{ID: fmt.Sprintf("c%d", i), Type: models.TypeNote, Content: "thoughts on " + books[i]},
+		)
+	}
+	return entries
+}
+
+func TestLearningFallsBackToRules(t *testing.T) {
+	learning := NewLearning(New(), t.TempDir())
+	learning.Train(trainingEntries()[:8])
+
+	entry := models.NewEntry("groceries cheese")
+	result := learning.CategoriseEntry(entry)
+	if entry.Type != models.TypeNote {
+		t.Errorf("expected the rules to decide with little training data, got %s", entry.Type)
+	}
+	if last := result.Reasons[len(result.Reasons)-1]; last != "learning model has seen 8 of 30 entries, used the rules" {
+		t.Errorf("expected the fallback to be explained, got %q", last)
+	}
+
+	link := models.NewEntry("Read https://go.dev/blog")
+	learning.CategoriseEntry(link)
+	if link.Type != models.TypeLink {
+		t.Errorf("expected a link from the rules, got %s", link.Type)
+	}
+}
+
+func TestLearningFromEntries(t *testing.T) {
+	dir := t.TempDir()
+	learning := NewLearning(New(), dir)
+	if err := learning.Train(trainingEntries()); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := models.NewEntry("groceries cheese")
+	result := learning.CategoriseEntry(entry)
+	if entry.Type != models.TypeTodo || entry.TodoStatus != models.TodoPending {
+		t.Errorf("expected a learned pending todo, got %s %q", entry.Type, entry.TodoStatus)
+	}
+	if result.Confidence < minConfidence || len(result.Reasons) < 2 || result.Reasons[1] != `words "groceries"` {
+		t.Errorf("expected a confident learned explanation, got %+v", result)
+	}
+	if !contains(entry.Tags, "todo") {
+		t.Errorf("expected the todo rule's tags, got %v", entry.Tags)
+	}
+
+	note := models.NewEntry("thoughts on dune")
+	learning.CategoriseEntry(note)
+	if note.Type != models.TypeNote {
+		t.Errorf("expected a note, got %s", note.Type)
+	}
+
+	// The saved model is picked up by a new categoriser
+	reloaded := NewLearning(New(), dir)
+	entry = models.NewEntry("groceries cheese")
+	reloaded.CategoriseEntry(entry)
+	if entry.Type != models.TypeTodo {
+		t.Errorf("expected the saved model to be loaded, got %s", entry.Type)
+	}
+}
+
+func TestLearnReplacesEarlierExample(t *testing.T) {
+	learning := NewLearning(New(), t.TempDir())
+	learning.Train(trainingEntries())
+
+	entry := models.Entry{ID: "x", Type: models.TypeNote, Content: "groceries cheese"}
+	learning.Learn(entry)
+	entry.Type = models.TypeTodo
+	learning.Learn(entry)
+
+	if learning.model.Docs[models.TypeNote] != 20 || learning.model.Docs[models.TypeTodo] != 21 {
+		t.Errorf("expected the correction to move the example, got %v", learning.model.Docs)
+	}
+	if learning.model.Words[models.TypeNote]["cheese"] != 0 {
+		t.Errorf("expected the old example's words to be forgotten")
+	}
+}
+
+func TestLearnForgetsEditedContent(t *testing.T) {
+	learning := NewLearning(New(), t.TempDir())
+	learning.Train(trainingEntries())
+
+	entry := models.Entry{ID: "x", Type: models.TypeNote, Content: "thoughts on cheese"}
+	learning.Learn(entry)
+	entry.Type, entry.Content = models.TypeTodo, "groceries cheddar"
+	learning.Learn(entry)
+
+	if learning.model.Words[models.TypeNote]["cheese"] != 0 {
+		t.Errorf("expected the words learned first to be forgotten")
+	}
+	if learning.model.Words[models.TypeNote]["thoughts"] != 10 {
+		t.Errorf("expected the other notes' words to be kept, got %d", learning.model.Words[models.TypeNote]["thoughts"])
+	}
+}
@@ -0,0 +1,86 @@
+package categorizer
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"stak/internal/models"
+)
+
+var codeFenceRegex = regexp.MustCompile("```([\\w+#-]*)")
+
+// document is the text being categorised, tokenized once and shared by
+// every rule
+type document struct {
+	text   string
+	tokens []string
+}
+
+func newDocument(text string) *document {
+	return &document{text: text, tokens: tokenize(text)}
+}
+
+// tokenize splits text into lower-cased words for keyword matching.
+// URLs are dropped and code fence markers are dropped keeping their
+// language name. A word is a run of letters and digits, with inner
+// apostrophes ("don't") and trailing + or # ("c++", "c#") kept; any other
+// punctuation separates words, so "node.js" is "node" and "js".
+func tokenize(text string) []string {
+	text = models.LinkRegex.ReplaceAllString(text, " ")
+	text = codeFenceRegex.ReplaceAllString(text, " $1 ")
+
+	var tokens []string
+	runes := []rune(strings.ToLower(text))
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) {
+			apostrophe := (runes[i] == '\'' || runes[i] == '’') && i+1 < len(runes) && isWordRune(runes[i+1])
+			if !isWordRune(runes[i]) && !apostrophe {
+				break
+			}
+			i++
+		}
+		for i < len(runes) && (runes[i] == '+' || runes[i] == '#') {
+			i++
+		}
+		tokens = append(tokens, strings.ReplaceAll(string(runes[start:i]), "’", "'"))
+	}
+
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// containsPhrase reports whether phrase appears as consecutive tokens
+func containsPhrase(tokens, phrase []string) bool {
+	if len(phrase) == 0 {
+		return false
+	}
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		if hasPrefixTokens(tokens[i:], phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPrefixTokens reports whether tokens starts with phrase
+func hasPrefixTokens(tokens, phrase []string) bool {
+	if len(phrase) == 0 || len(phrase) > len(tokens) {
+		return false
+	}
+	for i, word := range phrase {
+		if tokens[i] != word {
+			return false
+		}
+	}
+	return true
+}
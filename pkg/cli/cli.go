@@ -0,0 +1,707 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"stak/internal/application"
+	"stak/internal/config"
+	"stak/internal/models"
+	"stak/pkg/categorizer"
+	"stak/pkg/dateparse"
+	"stak/pkg/entities"
+	"stak/pkg/extractor"
+	"stak/pkg/linkcache"
+	"stak/pkg/search"
+	"stak/pkg/storage"
+)
+
+// Usage describes the non-interactive subcommands
+const Usage = `Commands:
+  stak add [--type T] [--date D] [--due D] [--priority P] [--merge|--force] [--json] <text|->
+                                                      capture an entry (- reads stdin); a
+                                                      link saved before is refused unless
+                                                      merged into that entry or forced
+  stak list [--type T] [--since D] [--until D] [--status S] [--json]
+  stak search [--links] [--json] <query>
+  stak done [--json] <id>                             mark a todo completed
+  stak cancel [--json] <id>                           mark a todo cancelled
+  stak reopen [--json] <id>                           mark a todo pending again
+  stak edit [--type T] [--due D] [--priority P] [--json] <id> [text|-]
+                                                      replace content, or open $EDITOR;
+                                                      --type alone only changes the type
+  stak rm <id>                                        move an entry to the trash
+  stak recategorize [--since D] [--until D] [--dry-run] [--json]
+                                                      re-run categorization over stored
+                                                      entries, skipping types set by hand
+  stak links check [--workers N] [--update] [--json]
+                                                      report dead and moved links of link
+                                                      entries; --update follows redirects
+  stak resolve                                        settle day files whose hand edited
+                                                      body conflicts with the front matter:
+                                                      the front matter wins and the file is
+                                                      kept as a .bak copy
+
+Dates are today, yesterday, tomorrow or YYYY-MM-DD; --due also takes
+YYYY-MM-DDTHH:MM or none. Priorities are high, medium, low or none.
+`
+
+// CLI runs stak subcommands through the entry service, for scripts, git
+// hooks and editor bindings that should not open the TUI
+type CLI struct {
+	config  *config.Config
+	service *application.EntryService
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+type command func(c *CLI, args []string) error
+
+var commands = map[string]command{
+	"add":          (*CLI).add,
+	"list":         (*CLI).list,
+	"search":       (*CLI).search,
+	"done":         (*CLI).done,
+	"cancel":       (*CLI).cancel,
+	"reopen":       (*CLI).reopen,
+	"edit":         (*CLI).edit,
+	"rm":           (*CLI).remove,
+	"recategorize": (*CLI).recategorize,
+	"links":        (*CLI).links,
+	"resolve":      (*CLI).resolve,
+}
+
+// IsCommand reports whether name is a CLI subcommand
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+func NewWithConfig(cfg *config.Config) *CLI {
+	return New(cfg, os.Stdin, os.Stdout)
+}
+
+func New(cfg *config.Config, stdin io.Reader, stdout io.Writer) *CLI {
+	// Create dependencies
+	storage := storage.New(cfg)
+	categoriser := categorizer.ForConfig(cfg)
+	searcher := search.NewFuzzySearcher()
+	extractor := extractor.NewLinkExtractorWithConfig(cfg.Links)
+
+	return &CLI{
+		config:  cfg,
+		service: application.NewEntryService(storage, categoriser, entities.NewWithConfig(cfg.Entities), extractor, linkcache.New(cfg.DataDir, cfg.Links.CacheTTL), searcher, dateparse.New()),
+		stdin:   stdin,
+		stdout:  stdout,
+		stderr:  os.Stderr,
+	}
+}
+
+// Run executes the subcommand named by args[0]
+func (c *CLI) Run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no command given\n\n%s", Usage)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], Usage)
+	}
+
+	err := cmd(c, args[1:])
+	// Let link titles land before the process exits
+	c.service.Wait()
+	for _, conflict := range c.service.BodyConflicts() {
+		fmt.Fprintf(c.stderr, "warning: %v; run stak resolve to keep the front matter\n", conflict)
+	}
+	return err
+}
+
+func (c *CLI) add(args []string) error {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	typeName := fs.String("type", "", "Force the entry type")
+	dateStr := fs.String("date", "", "Day to file the entry under")
+	due := fs.String("due", "", "Due date for a todo")
+	priority := fs.String("priority", "", "Priority for a todo")
+	merge := fs.Bool("merge", false, "Add to the entry that saved the same link before")
+	force := fs.Bool("force", false, "Save even if the same link was saved before")
+	asJSON := fs.Bool("json", false, "Print the entry as JSON")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	content, err := c.readContent(args)
+	if err != nil {
+		return err
+	}
+	if content == "" {
+		return fmt.Errorf("usage: stak add [--type T] [--date D] <text|->")
+	}
+	tokens, err := c.attributeTokens(*due, *priority)
+	if err != nil {
+		return err
+	}
+	content += tokens
+
+	if !*force {
+		duplicates, err := c.service.FindDuplicateLinks(content)
+		if err != nil {
+			return err
+		}
+		if len(duplicates) > 0 && *merge {
+			entry, err := c.service.MergeEntry(duplicates[0].Entry.ID, content)
+			if err != nil {
+				return err
+			}
+			return c.printEntries([]models.Entry{*entry}, *asJSON)
+		}
+		if len(duplicates) > 0 {
+			return duplicateError(duplicates)
+		}
+	}
+
+	var forceType *models.EntryType
+	if *typeName != "" {
+		entryType, ok := models.ParseEntryType(*typeName)
+		if !ok {
+			return fmt.Errorf("unknown type %q", *typeName)
+		}
+		forceType = &entryType
+	}
+
+	var entry *models.Entry
+	if *dateStr != "" {
+		day, err := c.parseDay(*dateStr)
+		if err != nil {
+			return err
+		}
+		// Keep the current time of day so entries stay in capture order
+		now := time.Now()
+		date := time.Date(day.Year(), day.Month(), day.Day(), now.Hour(), now.Minute(), now.Second(), 0, now.Location())
+		entry, err = c.service.CreateEntryForDate(content, date, forceType)
+	} else {
+		entry, err = c.service.CreateEntry(content, forceType)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.printEntries([]models.Entry{*entry}, *asJSON)
+}
+
+// duplicateError says where and when each repeated link was saved before
+func duplicateError(duplicates []application.LinkDuplicate) error {
+	var msg strings.Builder
+	for _, d := range duplicates {
+		fmt.Fprintf(&msg, "%s was saved on %s as %s: %q\n",
+			d.URL, d.Entry.CreatedAt.Format("2006-01-02 15:04"), d.Entry.ID, summarize(d.Entry.Content, 60))
+	}
+	msg.WriteString("use --merge to add to that entry, or --force to save it again")
+	return errors.New(msg.String())
+}
+
+func (c *CLI) list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	typeName := fs.String("type", "", "Only list entries of this type")
+	since := fs.String("since", "", "First day to list")
+	until := fs.String("until", "", "Last day to list")
+	status := fs.String("status", "", "Only list todos with this status")
+	asJSON := fs.Bool("json", false, "Print entries as JSON")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected argument %q", args[0])
+	}
+
+	var filter application.EntryFilter
+	if *typeName != "" {
+		entryType, ok := models.ParseEntryType(*typeName)
+		if !ok {
+			return fmt.Errorf("unknown type %q", *typeName)
+		}
+		filter.Type = entryType
+	}
+	if *status != "" {
+		todoStatus, ok := models.ParseTodoStatus(*status)
+		if !ok {
+			return fmt.Errorf("unknown status %q", *status)
+		}
+		filter.Status = todoStatus
+	}
+	if *since != "" {
+		if filter.Since, err = c.parseDay(*since); err != nil {
+			return err
+		}
+	}
+	if *until != "" {
+		if filter.Until, err = c.parseDay(*until); err != nil {
+			return err
+		}
+	}
+
+	entries, err := c.service.ListEntries(filter)
+	if err != nil {
+		return err
+	}
+	return c.printEntries(entries, *asJSON)
+}
+
+func (c *CLI) search(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	linksOnly := fs.Bool("links", false, "Only search links")
+	asJSON := fs.Bool("json", false, "Print entries as JSON")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	query := strings.Join(args, " ")
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("usage: stak search [--links] <query>")
+	}
+
+	entries, err := c.service.SearchEntries(query, *linksOnly)
+	if err != nil {
+		return err
+	}
+	return c.printEntries(entries, *asJSON)
+}
+
+func (c *CLI) done(args []string) error {
+	return c.setTodoStatus("done", models.TodoCompleted, args)
+}
+
+func (c *CLI) cancel(args []string) error {
+	return c.setTodoStatus("cancel", models.TodoCancelled, args)
+}
+
+func (c *CLI) reopen(args []string) error {
+	return c.setTodoStatus("reopen", models.TodoPending, args)
+}
+
+// setTodoStatus runs the subcommand name, which moves one todo to status
+func (c *CLI) setTodoStatus(name string, status models.TodoStatus, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the entry as JSON")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: stak %s <id>", name)
+	}
+
+	entry, err := c.service.SetTodoStatus(args[0], status)
+	if err != nil {
+		return err
+	}
+	return c.printEntries([]models.Entry{*entry}, *asJSON)
+}
+
+func (c *CLI) edit(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	typeName := fs.String("type", "", "Change the entry type")
+	due := fs.String("due", "", "Due date for a todo")
+	priority := fs.String("priority", "", "Priority for a todo")
+	asJSON := fs.Bool("json", false, "Print the entry as JSON")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("usage: stak edit <id> [text|-]")
+	}
+	tokens, err := c.attributeTokens(*due, *priority)
+	if err != nil {
+		return err
+	}
+	var entryType models.EntryType
+	if *typeName != "" {
+		var ok bool
+		if entryType, ok = models.ParseEntryType(*typeName); !ok {
+			return fmt.Errorf("unknown type %q", *typeName)
+		}
+	}
+
+	id := args[0]
+	existing, err := c.service.GetEntry(id)
+	if err != nil {
+		return err
+	}
+
+	var content string
+	switch {
+	case len(args) > 1:
+		content, err = c.readContent(args[1:])
+	case tokens != "" || entryType != "":
+		// Only the attributes are changing
+		content = existing.Content
+	default:
+		content, err = editInEditor(existing.Content)
+	}
+	if err != nil {
+		return err
+	}
+	if content == "" {
+		return fmt.Errorf("refusing to save empty content, use stak rm to delete")
+	}
+	entry := existing
+	if content != existing.Content || tokens != "" {
+		if entry, err = c.service.EditEntry(id, content+tokens, c.config.RecategorizeOnEdit); err != nil {
+			return err
+		}
+	}
+	if entryType != "" {
+		if entry, err = c.service.SetEntryType(id, entryType); err != nil {
+			return err
+		}
+	}
+	return c.printEntries([]models.Entry{*entry}, *asJSON)
+}
+
+func (c *CLI) remove(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: stak rm <id>")
+	}
+
+	if err := c.service.DeleteEntry(args[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Moved %s to the trash\n", args[0])
+	return nil
+}
+
+// resolve settles body conflicts in favour of the front matter
+func (c *CLI) resolve(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: stak resolve")
+	}
+
+	backups, err := c.service.ResolveBodyConflicts()
+	for _, backup := range backups {
+		fmt.Fprintf(c.stdout, "Kept the front matter, the edited file is in %s\n", backup)
+	}
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		fmt.Fprintln(c.stdout, "No day file conflicts")
+	}
+	return nil
+}
+
+// recategorize re-runs categorization over stored entries and lists what
+// changed, or would change with --dry-run
+func (c *CLI) recategorize(args []string) error {
+	fs := flag.NewFlagSet("recategorize", flag.ContinueOnError)
+	since := fs.String("since", "", "First day to recategorize")
+	until := fs.String("until", "", "Last day to recategorize")
+	dryRun := fs.Bool("dry-run", false, "Show the changes without saving them")
+	asJSON := fs.Bool("json", false, "Print the changes as JSON")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected argument %q", args[0])
+	}
+
+	var filter application.EntryFilter
+	if *since != "" {
+		if filter.Since, err = c.parseDay(*since); err != nil {
+			return err
+		}
+	}
+	if *until != "" {
+		if filter.Until, err = c.parseDay(*until); err != nil {
+			return err
+		}
+	}
+
+	changes, err := c.service.Recategorize(filter, !*dryRun)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		if changes == nil {
+			changes = []application.Recategorization{}
+		}
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	for _, change := range changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", change.Entry.ID, change, summarize(change.Entry.Content, 50))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	verb := "Recategorized"
+	if *dryRun {
+		verb = "Would recategorize"
+	}
+	fmt.Fprintf(c.stdout, "%s %d entries.\n", verb, len(changes))
+	return nil
+}
+
+// links runs the link subcommands, of which check is the only one
+func (c *CLI) links(args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return fmt.Errorf("usage: stak links check [--workers N] [--update] [--json]")
+	}
+
+	fs := flag.NewFlagSet("links check", flag.ContinueOnError)
+	workers := fs.Int("workers", 8, "How many links to check at once")
+	update := fs.Bool("update", false, "Change moved links to where they redirect")
+	asJSON := fs.Bool("json", false, "Print the dead and moved links as JSON")
+	args, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected argument %q", args[0])
+	}
+	if *workers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+
+	results, err := c.service.CheckLinks(*workers, *update)
+	if err != nil {
+		return err
+	}
+
+	broken := []application.LinkCheckResult{}
+	dead, moved := 0, 0
+	for _, r := range results {
+		switch {
+		case r.Check.Dead():
+			dead++
+		case r.Check.Moved():
+			moved++
+		default:
+			continue
+		}
+		broken = append(broken, r)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(broken)
+	}
+
+	if len(broken) > 0 {
+		w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STATE\tSTATUS\tURL\tDETAIL\tENTRY")
+		for _, r := range broken {
+			state, detail := "dead", r.Check.Error
+			if r.Check.Moved() {
+				state, detail = "moved", "→ "+r.Check.RedirectURL
+				if r.Updated {
+					detail += " (updated)"
+				}
+			}
+			status := "-"
+			if r.Check.Status != 0 {
+				status = fmt.Sprint(r.Check.Status)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", state, status, r.URL, detail, r.Entry.ID)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(c.stdout, "Checked %d links: %d dead, %d moved.\n", len(results), dead, moved)
+	return nil
+}
+
+// attributeTokens turns the --due and --priority flags into the inline
+// tokens the entry service reads from captured text
+func (c *CLI) attributeTokens(due, priority string) (string, error) {
+	var tokens string
+
+	if priority != "" {
+		if _, ok := models.ParsePriority(priority); !ok && priority != "none" {
+			return "", fmt.Errorf("unknown priority %q", priority)
+		}
+		tokens += " !" + priority
+	}
+
+	switch {
+	case due == "":
+	case due == "none":
+		tokens += " due:none"
+	case strings.Contains(due, "T"):
+		if _, err := time.ParseInLocation("2006-01-02T15:04", due, time.Local); err != nil {
+			return "", fmt.Errorf("invalid due date %q, use YYYY-MM-DDTHH:MM", due)
+		}
+		tokens += " due:" + due
+	default:
+		day, err := c.parseDay(due)
+		if err != nil {
+			return "", err
+		}
+		tokens += " due:" + day.Format("2006-01-02")
+	}
+
+	return tokens, nil
+}
+
+// parseFlags parses fs allowing flags after positional arguments, so
+// "stak done <id> --json" works like "stak done --json <id>". Everything
+// after "--" is positional.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		consumed := len(args) - fs.NArg()
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, fs.Args()...), nil
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// readContent joins args into entry content, reading stdin for a lone "-"
+func (c *CLI) readContent(args []string) (string, error) {
+	if len(args) == 1 && args[0] == "-" {
+		data, err := io.ReadAll(bufio.NewReader(c.stdin))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return strings.TrimSpace(strings.Join(args, " ")), nil
+}
+
+// parseDay accepts today, yesterday, tomorrow or a date in the configured
+// format, falling back to YYYY-MM-DD
+func (c *CLI) parseDay(value string) (time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch strings.ToLower(value) {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	for _, layout := range []string{c.config.DateFormat, "2006-01-02"} {
+		if day, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return day, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use today, yesterday, tomorrow or YYYY-MM-DD", value)
+}
+
+// editInEditor opens content in $VISUAL or $EDITOR and returns the result
+func editInEditor(content string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		return "", fmt.Errorf("no text given and $EDITOR is not set")
+	}
+
+	tmp, err := os.CreateTemp("", "stak-edit-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(content + "\n"); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	// The editor command may carry its own flags, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], tmp.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	data, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (c *CLI) printEntries(entries []models.Entry, asJSON bool) error {
+	if asJSON {
+		if entries == nil {
+			entries = []models.Entry{}
+		}
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(c.stdout, "No entries found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tTYPE\tSTATUS\tPRI\tDUE\tCONTENT")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.ID,
+			entry.CreatedAt.Format("2006-01-02 15:04"),
+			entry.Type,
+			entry.TodoStatus,
+			entry.Priority,
+			entry.FormatDue(),
+			summarize(entry.Content, 60),
+		)
+	}
+	return w.Flush()
+}
+
+// summarize returns the first line of content, cut to max runes
+func summarize(content string, max int) string {
+	line, _, more := strings.Cut(content, "\n")
+	runes := []rune(line)
+	if len(runes) > max {
+		return string(runes[:max-1]) + "…"
+	}
+	if more {
+		return line + " …"
+	}
+	return line
+}
@@ -0,0 +1,286 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"stak/internal/config"
+	"stak/internal/models"
+)
+
+func newTestCLI(t *testing.T, stdin string) (*CLI, *bytes.Buffer) {
+	t.Helper()
+
+	cfg := config.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	out := &bytes.Buffer{}
+	return New(cfg, strings.NewReader(stdin), out), out
+}
+
+func runJSON(t *testing.T, c *CLI, out *bytes.Buffer, args ...string) []models.Entry {
+	t.Helper()
+
+	out.Reset()
+	if err := c.Run(append(args, "--json")); err != nil {
+		t.Fatalf("stak %s failed: %v", strings.Join(args, " "), err)
+	}
+
+	var entries []models.Entry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON from stak %s: %v\n%s", strings.Join(args, " "), err, out.String())
+	}
+	return entries
+}
+
+func TestCommands(t *testing.T) {
+	c, out := newTestCLI(t, "note from stdin\n")
+
+	todo := runJSON(t, c, out, "add", "--json", "need to water the plants")
+	if len(todo) != 1 || todo[0].Type != models.TypeTodo {
+		t.Fatalf("expected a todo to be captured, got %v", todo)
+	}
+	id := todo[0].ID
+
+	if err := c.Run([]string{"add", "--type", "idea", "-"}); err != nil {
+		t.Fatalf("add from stdin failed: %v", err)
+	}
+	if err := c.Run([]string{"add", "--date", "yesterday", "old thought"}); err != nil {
+		t.Fatalf("add with date failed: %v", err)
+	}
+
+	ideas := runJSON(t, c, out, "list", "--type", "idea")
+	if len(ideas) != 1 || ideas[0].Content != "note from stdin" {
+		t.Errorf("expected the stdin entry as an idea, got %v", ideas)
+	}
+
+	today := runJSON(t, c, out, "list", "--since", "today")
+	if len(today) != 2 {
+		t.Errorf("expected 2 entries since today, got %d", len(today))
+	}
+
+	done := runJSON(t, c, out, "done", id)
+	if done[0].TodoStatus != models.TodoCompleted {
+		t.Errorf("expected completed todo, got %v", done[0].TodoStatus)
+	}
+	completed := runJSON(t, c, out, "list", "--status", "completed")
+	if len(completed) != 1 {
+		t.Errorf("expected 1 completed todo, got %d", len(completed))
+	}
+
+	cancelled := runJSON(t, c, out, "cancel", id)
+	if cancelled[0].TodoStatus != models.TodoCancelled || cancelled[0].CompletedAt != nil {
+		t.Errorf("expected cancelled todo, got %v", cancelled[0].TodoStatus)
+	}
+	reopened := runJSON(t, c, out, "reopen", id)
+	if reopened[0].TodoStatus != models.TodoPending {
+		t.Errorf("expected reopened todo, got %v", reopened[0].TodoStatus)
+	}
+	runJSON(t, c, out, "done", id)
+
+	edited := runJSON(t, c, out, "edit", id, "need to water the garden")
+	if edited[0].ID != id || edited[0].Content != "need to water the garden" {
+		t.Errorf("expected content edit keeping the ID, got %v", edited[0])
+	}
+	if !edited[0].CreatedAt.Equal(todo[0].CreatedAt) {
+		t.Errorf("expected creation time to be kept")
+	}
+
+	scheduled := runJSON(t, c, out, "edit", id, "--priority", "high", "--due", "2025-10-01")
+	if scheduled[0].Priority != models.PriorityHigh || scheduled[0].FormatDue() != "2025-10-01" {
+		t.Errorf("expected priority and due date to be set, got %q %q", scheduled[0].Priority, scheduled[0].FormatDue())
+	}
+	if scheduled[0].Content != "need to water the garden" {
+		t.Errorf("expected attribute flags to leave the content alone, got %q", scheduled[0].Content)
+	}
+
+	promoted := runJSON(t, c, out, "add", "dentist appointment", "--due", "tomorrow")
+	if promoted[0].Type != models.TypeTodo || promoted[0].DueAt == nil {
+		t.Errorf("expected a note with a due date to become a todo, got %v", promoted[0])
+	}
+
+	retyped := runJSON(t, c, out, "edit", promoted[0].ID, "--type", "meeting")
+	if retyped[0].Type != models.TypeMeeting || !retyped[0].CategorizedByHand() {
+		t.Errorf("expected the type to be changed by hand, got %s %v", retyped[0].Type, retyped[0].Metadata)
+	}
+
+	out.Reset()
+	if err := c.Run([]string{"recategorize", "--dry-run"}); err != nil {
+		t.Fatalf("recategorize failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Would recategorize 0 entries.") {
+		t.Errorf("expected nothing to recategorize, got:\n%s", out.String())
+	}
+
+	found := runJSON(t, c, out, "search", "garden")
+	if len(found) != 1 {
+		t.Errorf("expected search to find the edited entry, got %d", len(found))
+	}
+
+	if err := c.Run([]string{"rm", id}); err != nil {
+		t.Fatalf("rm failed: %v", err)
+	}
+	if err := c.Run([]string{"done", id}); err == nil {
+		t.Errorf("expected done on a removed entry to fail")
+	}
+
+	out.Reset()
+	if err := c.Run([]string{"list"}); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "ID") || strings.Contains(out.String(), id) {
+		t.Errorf("expected a table without the removed entry, got:\n%s", out.String())
+	}
+}
+
+func TestAddDuplicateLinks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<title>Post</title>`))
+	}))
+	defer srv.Close()
+	c, out := newTestCLI(t, "")
+
+	saved := runJSON(t, c, out, "add", "read "+srv.URL+"/post")
+	again := "reread " + srv.URL + "/post/?utm_source=feed"
+
+	err := c.Run([]string{"add", again})
+	if err == nil || !strings.Contains(err.Error(), saved[0].ID) || !strings.Contains(err.Error(), "--merge") {
+		t.Fatalf("expected the earlier entry to be named, got %v", err)
+	}
+
+	merged := runJSON(t, c, out, "add", "--merge", again)
+	if len(merged) != 1 || merged[0].ID != saved[0].ID || merged[0].Content != "read "+srv.URL+"/post\nreread" {
+		t.Errorf("expected the capture merged into the earlier entry, got %+v", merged)
+	}
+
+	runJSON(t, c, out, "add", "--force", again)
+	links := runJSON(t, c, out, "list", "--type", "link")
+	if len(links) != 2 {
+		t.Errorf("expected --force to save a second entry, got %d", len(links))
+	}
+}
+
+func TestLinksCheck(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c, out := newTestCLI(t, "")
+
+	for _, path := range []string{"/ok", "/gone", "/old"} {
+		if err := c.Run([]string{"add", "read " + srv.URL + path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out.Reset()
+	if err := c.Run([]string{"links", "check", "--workers", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	report := out.String()
+	for _, want := range []string{"dead   410", "moved  200", "→ " + srv.URL + "/ok", "Checked 3 links: 1 dead, 1 moved."} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q in the report:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "(updated)") {
+		t.Errorf("expected nothing updated without --update:\n%s", report)
+	}
+
+	out.Reset()
+	if err := c.Run([]string{"links", "check", "--update", "--json"}); err != nil {
+		t.Fatal(err)
+	}
+	var results []struct {
+		URL     string `json:"url"`
+		Updated bool   `json:"updated"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(results) != 2 {
+		t.Errorf("expected the dead and moved links, got %+v", results)
+	}
+
+	links := runJSON(t, c, out, "search", "--links", "read")
+	if len(links) != 3 {
+		t.Fatalf("expected 3 link entries, got %d", len(links))
+	}
+	for _, entry := range links {
+		if strings.HasSuffix(entry.Content, "/old") {
+			t.Errorf("expected --update to follow the redirect, got %q", entry.Content)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	c, out := newTestCLI(t, "")
+	stderr := &bytes.Buffer{}
+	c.stderr = stderr
+
+	added := runJSON(t, c, out, "add", "need to water the plants")
+	paths, _ := filepath.Glob(filepath.Join(c.config.DataDir, "*.md"))
+	if len(added) != 1 || len(paths) != 1 {
+		t.Fatalf("expected one entry in one day file, got %v in %v", added, paths)
+	}
+
+	// Edit the same entry in the front matter and the body
+	content, _ := os.ReadFile(paths[0])
+	edited := strings.Replace(string(content), "content: need to water the plants", "content: need to water the garden", 1)
+	edited = strings.Replace(edited, "- [ ] need to water the plants", "- [ ] need to water the lawn", 1)
+	if err := os.WriteFile(paths[0], []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runJSON(t, c, out, "list")
+	if !strings.Contains(stderr.String(), "stak resolve") {
+		t.Errorf("expected the conflict to be reported on load, got %q", stderr.String())
+	}
+
+	out.Reset()
+	stderr.Reset()
+	if err := c.Run([]string{"resolve"}); err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if !strings.Contains(out.String(), paths[0]+".bak") || stderr.Len() != 0 {
+		t.Errorf("expected the backup to be listed and no warning, got %q and %q", out.String(), stderr.String())
+	}
+	if entries := runJSON(t, c, out, "list"); len(entries) != 1 || entries[0].Content != "need to water the garden" {
+		t.Errorf("expected the front matter to win, got %v", entries)
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	c, _ := newTestCLI(t, "")
+
+	tests := [][]string{
+		{"nope"},
+		{"add"},
+		{"add", "--type", "bogus", "text"},
+		{"list", "--since", "someday"},
+		{"list", "--status", "maybe"},
+		{"add", "--priority", "urgent", "text"},
+		{"add", "--due", "someday", "text"},
+		{"done", "missing-id"},
+		{"links"},
+		{"links", "check", "--workers", "0"},
+		{"resolve", "everything"},
+	}
+
+	for _, args := range tests {
+		if err := c.Run(args); err == nil {
+			t.Errorf("expected stak %s to fail", strings.Join(args, " "))
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package dateparse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"stak/internal/models"
+	"stak/internal/ports"
+)
+
+// Compile-time check to ensure Parser implements DateParserPort
+var _ ports.DateParserPort = (*Parser)(nil)
+
+// wordJoiners are the characters that make a date word part of a longer one
+const wordJoiners = "/\\-_'’@"
+
+const weekdayPattern = `monday|tuesday|wednesday|thursday|friday|saturday|sunday`
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// horizonYears bounds how far ahead a date may be; anything later, as "in
+// 9999 months" is, is not taken for a date
+const horizonYears = 10
+
+var numberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+}
+
+// rule matches one kind of date expression. resolve returns the day the
+// match refers to, at midnight, or false when the match is not a date.
+type rule struct {
+	regex   *regexp.Regexp
+	resolve func(match []string, today time.Time) (time.Time, bool)
+	// needsTime rejects matches not followed by a time of day
+	needsTime func(match []string) bool
+	// definite reports whether the match names its day even without a time
+	// of day, see ports.DateMatch
+	definite func(match []string) bool
+}
+
+func always([]string) bool { return true }
+
+// Parser finds date expressions such as "tomorrow", "next friday",
+// "in 3 days", "on 2025-10-01", "monday 3pm" and "end of month" in text
+type Parser struct {
+	rules     []rule
+	timeRegex *regexp.Regexp
+}
+
+func New() *Parser {
+	return &Parser{
+		rules: []rule{
+			{
+				regex: regexp.MustCompile(`(?i)\b(today|tonight)\b`),
+				resolve: func(_ []string, today time.Time) (time.Time, bool) {
+					return today, true
+				},
+			},
+			{
+				regex: regexp.MustCompile(`(?i)\b(?:the\s+)?day\s+after\s+tomorrow\b`),
+				resolve: func(_ []string, today time.Time) (time.Time, bool) {
+					return today.AddDate(0, 0, 2), true
+				},
+				definite: always,
+			},
+			{
+				regex: regexp.MustCompile(`(?i)\btomorrow\b`),
+				resolve: func(_ []string, today time.Time) (time.Time, bool) {
+					return today.AddDate(0, 0, 1), true
+				},
+				definite: always,
+			},
+			{
+				// "friday" is the coming friday, today included; "next
+				// friday" is the first friday after today
+				regex: regexp.MustCompile(`(?i)\b(by\s+)?(?:(next|this|on)\s+)?(` + weekdayPattern + `)\b`),
+				resolve: func(match []string, today time.Time) (time.Time, bool) {
+					ahead := (int(weekdays[strings.ToLower(match[3])]) - int(today.Weekday()) + 7) % 7
+					if ahead == 0 && strings.EqualFold(match[2], "next") {
+						ahead = 7
+					}
+					return today.AddDate(0, 0, ahead), true
+				},
+				// A bare weekday is too often just a word in a sentence
+				needsTime: func(match []string) bool {
+					return match[1] == "" && match[2] == ""
+				},
+				definite: func(match []string) bool {
+					return strings.EqualFold(match[2], "next")
+				},
+			},
+			{
+				regex: regexp.MustCompile(`(?i)\bnext\s+(week|month|year)\b`),
+				resolve: func(match []string, today time.Time) (time.Time, bool) {
+					switch strings.ToLower(match[1]) {
+					case "week":
+						ahead := (int(time.Monday) - int(today.Weekday()) + 7) % 7
+						if ahead == 0 {
+							ahead = 7
+						}
+						return today.AddDate(0, 0, ahead), true
+					case "month":
+						return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()), true
+					default:
+						return time.Date(today.Year()+1, time.January, 1, 0, 0, 0, 0, today.Location()), true
+					}
+				},
+				definite: always,
+			},
+			{
+				regex: regexp.MustCompile(`(?i)\bin\s+(\d{1,4}|an?|one|two|three|four|five|six|seven|eight|nine|ten)\s+(days?|weeks?|months?)\b`),
+				resolve: func(match []string, today time.Time) (time.Time, bool) {
+					n, ok := numberWords[strings.ToLower(match[1])]
+					if !ok {
+						var err error
+						if n, err = strconv.Atoi(match[1]); err != nil {
+							return time.Time{}, false
+						}
+					}
+					switch unit := strings.ToLower(match[2]); {
+					case strings.HasPrefix(unit, "day"):
+						return today.AddDate(0, 0, n), true
+					case strings.HasPrefix(unit, "week"):
+						return today.AddDate(0, 0, 7*n), true
+					default:
+						return addMonths(today, n), true
+					}
+				},
+				definite: always,
+			},
+			{
+				// The end of the week is the coming friday
+				regex: regexp.MustCompile(`(?i)\b(?:by\s+)?(?:the\s+)?end\s+of\s+(?:the\s+)?(week|month)\b`),
+				resolve: func(match []string, today time.Time) (time.Time, bool) {
+					if strings.EqualFold(match[1], "week") {
+						ahead := (int(time.Friday) - int(today.Weekday()) + 7) % 7
+						return today.AddDate(0, 0, ahead), true
+					}
+					return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, today.Location()), true
+				},
+			},
+			{
+				regex: regexp.MustCompile(`(?i)\b(?:on|by)\s+(\d{4}-\d{2}-\d{2})\b`),
+				resolve: func(match []string, today time.Time) (time.Time, bool) {
+					day, err := time.ParseInLocation("2006-01-02", match[1], today.Location())
+					return day, err == nil
+				},
+				definite: always,
+			},
+		},
+		// 3pm, 3:30 pm, 15:00 and "at 9", each optionally led by "at"
+		timeRegex: regexp.MustCompile(`(?i)^,?[ \t]+(at[ \t]+)?(\d{1,2})(?::(\d{2}))?[ \t]*(am|pm)?\b`),
+	}
+}
+
+// ParseDate finds the first date expression in text, relative to now, up
+// to ten years ahead. The returned text has the expression and any time of
+// day after it removed.
+// Expressions in code or links, or that are part of a longer word such as
+// a path, "tomorrow-in-tech" or "tomorrow's", are not dates.
+func (p *Parser) ParseDate(text string, now time.Time) (ports.DateMatch, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	quoted := models.QuotedSpans(text)
+
+	var best ports.DateMatch
+	bestStart, bestEnd := -1, -1
+	for _, r := range p.rules {
+		for _, loc := range r.regex.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			// Only a match left of, or as far left as and longer than, the
+			// best so far can win
+			if bestStart >= 0 && (start > bestStart || (start == bestStart && end <= bestEnd)) {
+				continue
+			}
+			if models.InSpan(quoted, start, end) || !standalone(text, start, end) {
+				continue
+			}
+
+			match := submatches(text, loc)
+			day, ok := r.resolve(match, today)
+			if !ok || day.After(today.AddDate(horizonYears, 0, 0)) {
+				continue
+			}
+
+			hour, minute, timeEnd, hasTime := p.parseTime(text[end:])
+			if !hasTime && r.needsTime != nil && r.needsTime(match) {
+				continue
+			}
+			if hasTime {
+				day = time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+				end += timeEnd
+			}
+
+			definite := hasTime || (r.definite != nil && r.definite(match))
+			best = ports.DateMatch{Time: day, HasTime: hasTime, Definite: definite}
+			bestStart, bestEnd = start, end
+		}
+	}
+
+	if bestStart < 0 {
+		return ports.DateMatch{}, false
+	}
+	best.Text = splice(text, bestStart, bestEnd)
+	return best, true
+}
+
+// parseTime reads a time of day at the start of rest, returning how much of
+// rest it used. A bare number only counts as a time after "at".
+func (p *Parser) parseTime(rest string) (hour, minute, length int, ok bool) {
+	loc := p.timeRegex.FindStringSubmatchIndex(rest)
+	if loc == nil {
+		return 0, 0, 0, false
+	}
+	match := submatches(rest, loc)
+	at, minutes, meridiem := match[1] != "", match[3], strings.ToLower(match[4])
+	if !at && minutes == "" && meridiem == "" {
+		return 0, 0, 0, false
+	}
+
+	hour, _ = strconv.Atoi(match[2])
+	if minutes != "" {
+		minute, _ = strconv.Atoi(minutes)
+	}
+	if minute > 59 || hour > 23 || (meridiem != "" && (hour < 1 || hour > 12)) {
+		return 0, 0, 0, false
+	}
+	switch {
+	case meridiem == "am" && hour == 12:
+		hour = 0
+	case meridiem == "pm" && hour < 12:
+		hour += 12
+	}
+	return hour, minute, loc[1], true
+}
+
+// standalone reports whether text[start:end] stands apart from the words
+// around it, rather than running on into a path, a hyphenated word, a
+// possessive or a file name
+func standalone(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	if strings.ContainsRune(wordJoiners, before) || before == '.' {
+		return false
+	}
+	after, size := utf8.DecodeRuneInString(text[end:])
+	if strings.ContainsRune(wordJoiners, after) {
+		return false
+	}
+	// A full stop ends a sentence, but not a name such as today.md
+	next, _ := utf8.DecodeRuneInString(text[min(end+size, len(text)):])
+	return after != '.' || !(unicode.IsLetter(next) || unicode.IsDigit(next))
+}
+
+func submatches(text string, loc []int) []string {
+	match := make([]string, len(loc)/2)
+	for i := range match {
+		if loc[2*i] >= 0 {
+			match[i] = text[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return match
+}
+
+// splice removes text[start:end], joining what is left either side with a
+// single space and leaving line breaks elsewhere alone
+func splice(text string, start, end int) string {
+	left := strings.TrimRight(text[:start], " \t")
+	right := strings.TrimLeft(text[end:], " \t")
+	if left == "" || right == "" || strings.HasSuffix(left, "\n") || strings.HasPrefix(right, "\n") {
+		return strings.TrimSpace(left + right)
+	}
+	return strings.TrimSpace(left + " " + right)
+}
+
+// addMonths moves n months on, clamping to the end of shorter months so
+// January 31 plus one month is the last day of February
+func addMonths(day time.Time, n int) time.Time {
+	first := time.Date(day.Year(), day.Month()+time.Month(n), 1, 0, 0, 0, 0, day.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	d := day.Day()
+	if d > last {
+		d = last
+	}
+	return time.Date(first.Year(), first.Month(), d, 0, 0, 0, 0, day.Location())
+}
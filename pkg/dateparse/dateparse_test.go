@@ -0,0 +1,126 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	// Wednesday
+	now := time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local)
+	parser := New()
+
+	tests := []struct {
+		input   string
+		want    string // "" when no date should be found
+		hasTime bool
+		text    string
+	}{
+		{"tomorrow buy milk", "2025-09-11 00:00", false, "buy milk"},
+		{"call mom Tomorrow", "2025-09-11 00:00", false, "call mom"},
+		{"finish report today", "2025-09-10 00:00", false, "finish report"},
+		{"dentist the day after tomorrow", "2025-09-12 00:00", false, "dentist"},
+		{"demo next friday", "2025-09-12 00:00", false, "demo"},
+		{"review next wednesday", "2025-09-17 00:00", false, "review"},
+		{"retro this wednesday", "2025-09-10 00:00", false, "retro"},
+		{"submit expenses by friday", "2025-09-12 00:00", false, "submit expenses"},
+		{"standup monday 3pm", "2025-09-15 15:00", true, "standup"},
+		{"monday at 9:30 planning", "2025-09-15 09:30", true, "planning"},
+		{"sync tomorrow, 12am", "2025-09-11 00:00", true, "sync"},
+		{"lunch tomorrow at 12", "2025-09-11 12:00", true, "lunch"},
+		{"renew passport in 3 days", "2025-09-13 00:00", false, "renew passport"},
+		{"book flights in two weeks", "2025-09-24 00:00", false, "book flights"},
+		{"check invoices in a month", "2025-10-10 00:00", false, "check invoices"},
+		{"release on 2025-10-01 17:00", "2025-10-01 17:00", true, "release"},
+		{"taxes by end of month", "2025-09-30 00:00", false, "taxes"},
+		{"wrap up the end of the week", "2025-09-12 00:00", false, "wrap up"},
+		{"plan offsite next week", "2025-09-15 00:00", false, "plan offsite"},
+		{"budget next month", "2025-10-01 00:00", false, "budget"},
+		{"tomorrow\n  go test ./...", "2025-09-11 00:00", false, "go test ./..."},
+
+		// Words that only look like dates
+		{"the monday meeting went well", "", false, ""},
+		{"2025-10-01 was a good release", "", false, ""},
+		{"tomorrow 2 people join", "2025-09-11 00:00", false, "2 people join"},
+		{"todays standup", "", false, ""},
+		{"in 3 dayz", "", false, ""},
+		{"in 100000000 months", "", false, ""},
+		{"in 200 months", "", false, ""},
+		{"launch on 2099-01-01", "", false, ""},
+		{"in 120 months", "2035-09-10 00:00", false, ""},
+
+		// Dates inside links, code, paths and longer words
+		{"https://example.com/blog/today", "", false, ""},
+		{"see https://news.example.com/2025/tomorrow-in-tech", "", false, ""},
+		{"```\nconst today = new Date()\n```", "", false, ""},
+		{"run `sleep until tomorrow` first", "", false, ""},
+		{"Read about tomorrow's weather", "", false, ""},
+		{"notes in docs/today.md", "", false, ""},
+		{"the tomorrow-in-tech newsletter", "", false, ""},
+		{"see https://example.com/today and ship it tomorrow", "2025-09-11 00:00", false, "see https://example.com/today and ship it"},
+		{"Tomorrow's weather is due today", "2025-09-10 00:00", false, "Tomorrow's weather is due"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			match, ok := parser.ParseDate(tt.input, now)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("expected no date, got %v", match.Time)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("expected a date in %q", tt.input)
+			}
+			if got := match.Time.Format("2006-01-02 15:04"); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			if match.HasTime != tt.hasTime {
+				t.Errorf("expected HasTime %v, got %v", tt.hasTime, match.HasTime)
+			}
+			if match.Text != tt.text {
+				t.Errorf("expected text %q, got %q", tt.text, match.Text)
+			}
+		})
+	}
+}
+
+func TestParseDateDefinite(t *testing.T) {
+	now := time.Date(2025, 9, 10, 14, 30, 0, 0, time.Local)
+	parser := New()
+
+	tests := []struct {
+		input    string
+		definite bool
+	}{
+		{"demo tomorrow", true},
+		{"demo next friday", true},
+		{"plan offsite next week", true},
+		{"renew passport in 3 days", true},
+		{"release on 2025-10-01", true},
+		{"standup monday 3pm", true},
+		{"met Bob on monday", false},
+		{"submit expenses by friday", false},
+		{"finish report today", false},
+		{"taxes by end of month", false},
+	}
+
+	for _, tt := range tests {
+		match, ok := parser.ParseDate(tt.input, now)
+		if !ok {
+			t.Errorf("expected a date in %q", tt.input)
+			continue
+		}
+		if match.Definite != tt.definite {
+			t.Errorf("%q: expected Definite %v, got %v", tt.input, tt.definite, match.Definite)
+		}
+	}
+}
+
+func TestAddMonthsClampsToMonthEnd(t *testing.T) {
+	jan31 := time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local)
+	if got := addMonths(jan31, 1).Format("2006-01-02"); got != "2025-02-28" {
+		t.Errorf("expected 2025-02-28, got %s", got)
+	}
+}
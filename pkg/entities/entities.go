@@ -0,0 +1,153 @@
+package entities
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+	"stak/internal/config"
+	"stak/internal/models"
+	"stak/internal/ports"
+)
+
+// Compile-time check to ensure Extractor implements EntityExtractorPort
+var _ ports.EntityExtractorPort = (*Extractor)(nil)
+
+//go:embed patterns.yaml
+var defaultPatternsYAML []byte
+
+// checks weed out matches a shipped pattern cannot rule out on its own,
+// given the match and the text before it
+var checks = map[string]func(match, before string) bool{
+	// A commit SHA mixes digits and letters; "defaced" and "1234567" are not
+	"commit": func(match, _ string) bool {
+		return strings.ContainsFunc(match, unicode.IsDigit) && strings.ContainsFunc(match, unicode.IsLetter)
+	},
+	// Standards and encodings look like ticket IDs but are not
+	"ticket": func(match, _ string) bool {
+		prefix, _, _ := strings.Cut(match, "-")
+		return !notTicketPrefixes[prefix]
+	},
+	// A name in a path, as in "./deploy.sh" or "docs/notes.md", is a file
+	"hostname": func(_, before string) bool {
+		return !strings.HasSuffix(before, "/")
+	},
+}
+
+var notTicketPrefixes = map[string]bool{
+	"UTF": true, "ISO": true, "SHA": true, "RFC": true, "AES": true,
+	"MD": true, "CVE": true, "COVID": true, "IPV": true, "HTTP": true,
+}
+
+// pattern is a compiled config.EntityPattern
+type pattern struct {
+	kind  string
+	regex *regexp.Regexp
+	url   string
+}
+
+// Extractor finds entities such as email addresses, ticket IDs, GitHub
+// references, commit SHAs, IP addresses and hostnames in entry text
+type Extractor struct {
+	patterns []pattern
+}
+
+// New returns an extractor using only the shipped patterns
+func New() *Extractor {
+	return NewWithConfig(config.EntitiesConfig{})
+}
+
+// NewWithConfig returns an extractor using the shipped patterns merged with
+// the config's. Disabled and empty patterns find nothing, so they are left
+// out, along with any the regexp package rejects.
+func NewWithConfig(cfg config.EntitiesConfig) *Extractor {
+	e := &Extractor{}
+	for _, p := range cfg.Merge(DefaultPatterns()) {
+		if p.Disabled || p.Pattern == "" {
+			continue
+		}
+		expr := p.Pattern
+		if !p.CaseSensitive {
+			expr = "(?i)" + expr
+		}
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			continue
+		}
+		e.patterns = append(e.patterns, pattern{kind: p.Kind, regex: regex, url: p.URL})
+	}
+	return e
+}
+
+// DefaultPatterns returns the shipped patterns
+func DefaultPatterns() []config.EntityPattern {
+	var patterns []config.EntityPattern
+	if err := yaml.Unmarshal(defaultPatternsYAML, &patterns); err != nil {
+		panic(fmt.Sprintf("invalid shipped entity patterns: %v", err))
+	}
+	return patterns
+}
+
+// match is one pattern's hit in the text being searched
+type match struct {
+	start, end int
+	order      int // the pattern's position, to break ties
+	entity     models.Entity
+}
+
+// ExtractEntities returns the entities in content in the order they appear,
+// each distinct value once
+func (e *Extractor) ExtractEntities(content string) []models.Entity {
+	// Blank out links, which are the link extractor's, so their hosts and
+	// paths are not matched, keeping offsets intact
+	text := models.LinkRegex.ReplaceAllStringFunc(content, func(url string) string {
+		return strings.Repeat(" ", len(url))
+	})
+
+	var matches []match
+	for order, p := range e.patterns {
+		for _, loc := range p.regex.FindAllStringSubmatchIndex(text, -1) {
+			value := text[loc[0]:loc[1]]
+			if check, ok := checks[p.kind]; ok && !check(value, text[:loc[0]]) {
+				continue
+			}
+			entity := models.Entity{Kind: p.kind, Value: value}
+			if p.url != "" {
+				entity.URL = string(p.regex.ExpandString(nil, p.url, text, loc))
+			}
+			matches = append(matches, match{start: loc[0], end: loc[1], order: order, entity: entity})
+		}
+	}
+
+	// The earliest match wins an overlap, then the longest, then the
+	// pattern listed first
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.start != b.start {
+			return a.start < b.start
+		}
+		if a.end != b.end {
+			return a.end > b.end
+		}
+		return a.order < b.order
+	})
+
+	var entities []models.Entity
+	seen := make(map[models.Entity]bool)
+	end := 0
+	for _, m := range matches {
+		if m.start < end {
+			continue
+		}
+		end = m.end
+		if !seen[m.entity] {
+			seen[m.entity] = true
+			entities = append(entities, m.entity)
+		}
+	}
+	return entities
+}
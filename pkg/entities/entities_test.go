@@ -0,0 +1,118 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+
+	"stak/internal/config"
+	"stak/internal/models"
+)
+
+func TestExtractEntities(t *testing.T) {
+	extractor := New()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []models.Entity
+	}{
+		{
+			name:    "Email, ticket and GitHub reference",
+			content: "mail sam@example.com about PROJ-1234 and golang/go#42",
+			expected: []models.Entity{
+				{Kind: "email", Value: "sam@example.com", URL: "mailto:sam@example.com"},
+				{Kind: "ticket", Value: "PROJ-1234"},
+				{Kind: "github", Value: "golang/go#42", URL: "https://github.com/golang/go/issues/42"},
+			},
+		},
+		{
+			name:    "Commit, IP address and hostname",
+			content: "fixed in 3f9a2c1d, deployed to 10.0.0.12 and api.example.com",
+			expected: []models.Entity{
+				{Kind: "commit", Value: "3f9a2c1d"},
+				{Kind: "ip", Value: "10.0.0.12"},
+				{Kind: "hostname", Value: "api.example.com", URL: "https://api.example.com"},
+			},
+		},
+		{
+			name:     "Links are left to the categorizer",
+			content:  "read https://api.example.com/v1/users?id=abc1234 later",
+			expected: nil,
+		},
+		{
+			name:     "Lookalikes are ignored",
+			content:  "a defaced facade, 1234567 bytes, UTF-8 and SHA-256, node.js, 999.1.1.1",
+			expected: nil,
+		},
+		{
+			name:     "File names are not hostnames",
+			content:  "ran deploy.sh, then ./notes.com and scripts/setup.dev, logo.ai is in app.co",
+			expected: nil,
+		},
+		{
+			name:    "Extension-like TLDs need a subdomain",
+			content: "status of api.fly.io",
+			expected: []models.Entity{
+				{Kind: "hostname", Value: "api.fly.io", URL: "https://api.fly.io"},
+			},
+		},
+		{
+			name:    "Each value once, hostnames inside emails not repeated",
+			content: "ops@example.com and ops@example.com",
+			expected: []models.Entity{
+				{Kind: "email", Value: "ops@example.com", URL: "mailto:ops@example.com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractor.ExtractEntities(tt.content)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ExtractEntities(%q)\n got %+v\nwant %+v", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfiguredPatterns(t *testing.T) {
+	extractor := NewWithConfig(config.EntitiesConfig{Patterns: []config.EntityPattern{
+		{Kind: "ticket", URL: "https://tracker.example.com/browse/$0"},
+		{Kind: "incident", Pattern: `\bINC(?P<id>\d{4,})\b`, URL: "https://status.example.com/incidents/${id}", CaseSensitive: true},
+		{Kind: "ip", Disabled: true},
+	}})
+
+	got := extractor.ExtractEntities("PROJ-7 caused INC20931 on 10.0.0.12")
+	expected := []models.Entity{
+		{Kind: "ticket", Value: "PROJ-7", URL: "https://tracker.example.com/browse/PROJ-7"},
+		{Kind: "incident", Value: "INC20931", URL: "https://status.example.com/incidents/20931"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %+v\nwant %+v", got, expected)
+	}
+}
+
+func TestShippedPatterns(t *testing.T) {
+	patterns := DefaultPatterns()
+	if err := (config.EntitiesConfig{Patterns: patterns}).Validate(); err != nil {
+		t.Errorf("shipped patterns are invalid: %v", err)
+	}
+	if got := len(New().patterns); got != len(patterns) {
+		t.Errorf("expected all %d shipped patterns to be used, got %d", len(patterns), got)
+	}
+
+	// A config pattern overrides the shipped one of its kind, so kinds must
+	// be unique, and a check for a kind that is not shipped never runs
+	kinds := make(map[string]bool)
+	for _, p := range patterns {
+		if kinds[p.Kind] {
+			t.Errorf("kind %q is shipped twice", p.Kind)
+		}
+		kinds[p.Kind] = true
+	}
+	for kind := range checks {
+		if !kinds[kind] {
+			t.Errorf("check for %q has no shipped pattern", kind)
+		}
+	}
+}
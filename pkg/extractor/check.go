@@ -0,0 +1,28 @@
+package extractor
+
+import (
+	"net/http"
+
+	"stak/internal/models"
+)
+
+// CheckURL asks for rawURL with a HEAD request, following redirects up to
+// the configured limit. Servers that refuse or mishandle HEAD are asked
+// again with GET, whose body is never read.
+func (le *LinkExtractor) CheckURL(rawURL string) (models.LinkCheck, error) {
+	resp, err := le.request(http.MethodHead, rawURL)
+	if err == nil && resp.StatusCode >= 400 {
+		resp.Body.Close()
+		resp, err = le.request(http.MethodGet, rawURL)
+	}
+	if err != nil {
+		return models.LinkCheck{}, err
+	}
+	resp.Body.Close()
+
+	check := models.LinkCheck{Status: resp.StatusCode}
+	if final := le.NormalizeURL(resp.Request.URL.String()); final != le.NormalizeURL(rawURL) {
+		check.RedirectURL = final
+	}
+	return check, nil
+}
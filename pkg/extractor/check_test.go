@@ -0,0 +1,72 @@
+package extractor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"stak/internal/models"
+)
+
+func TestCheckURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok?utm_source=redirect", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/slash", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/slash/", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/slash/", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			http.Error(w, "", http.StatusMethodNotAllowed)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected models.LinkCheck
+		dead     bool
+		moved    bool
+	}{
+		{"Working link", "/ok", models.LinkCheck{Status: 200}, false, false},
+		{"Gone", "/gone", models.LinkCheck{Status: 410}, true, false},
+		{"Server error", "/broken", models.LinkCheck{Status: 500}, true, false},
+		{"Redirect to another page", "/moved", models.LinkCheck{Status: 200, RedirectURL: srv.URL + "/ok"}, false, true},
+		{"Redirect to the same normalized address", "/slash", models.LinkCheck{Status: 200}, false, false},
+		{"HEAD refused, GET works", "/no-head", models.LinkCheck{Status: 200}, false, false},
+		{"Missing page", "/missing", models.LinkCheck{Status: 404}, true, false},
+	}
+
+	le := NewLinkExtractor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := le.CheckURL(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %+v, want %+v", got, tt.expected)
+			}
+			if got.Dead() != tt.dead || got.Moved() != tt.moved {
+				t.Errorf("expected dead %v and moved %v, got %v and %v", tt.dead, tt.moved, got.Dead(), got.Moved())
+			}
+		})
+	}
+
+	closed := httptest.NewServer(mux)
+	closed.Close()
+	if _, err := le.CheckURL(closed.URL + "/ok"); err == nil {
+		t.Error("expected an unreachable server to fail")
+	}
+}
@@ -1,82 +1,347 @@
 package extractor
 
 import (
+	"bufio"
+	"cmp"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"stak/internal/config"
+	"stak/internal/models"
 	"stak/internal/ports"
 )
 
 // Compile-time check to ensure LinkExtractor implements ExtractorPort
 var _ ports.ExtractorPort = (*LinkExtractor)(nil)
 
+// Titles and descriptions longer than this many characters are cut short
+const (
+	maxTitleLength       = 100
+	maxDescriptionLength = 300
+)
+
+// Defaults for the zero values of config.LinksConfig
+const (
+	defaultUserAgent    = "Mozilla/5.0 (compatible; stak link preview)"
+	defaultTimeout      = 5 * time.Second
+	defaultMaxRedirects = 5
+	defaultMaxBytes     = 1 << 20
+)
+
 type LinkExtractor struct {
-	client *http.Client
-	urlRegex *regexp.Regexp
+	client      *http.Client
+	userAgent   string
+	maxBytes    int64
+	stripParams []string
 }
 
+// NewLinkExtractor returns an extractor with the default fetch settings
 func NewLinkExtractor() *LinkExtractor {
+	return NewLinkExtractorWithConfig(config.LinksConfig{})
+}
+
+// NewLinkExtractorWithConfig returns an extractor fetching pages as cfg
+// says. Requests go through cfg.Proxy when it parses as a URL, and through
+// the proxy named by the environment otherwise.
+func NewLinkExtractorWithConfig(cfg config.LinksConfig) *LinkExtractor {
+	timeout := cmp.Or(cfg.Timeout, defaultTimeout)
+	maxRedirects := cmp.Or(cfg.MaxRedirects, defaultMaxRedirects)
+	stripParams := cfg.StripParams
+	if stripParams == nil {
+		stripParams = defaultStripParams
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy, err := url.Parse(cfg.Proxy); cfg.Proxy != "" && err == nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
 	return &LinkExtractor{
 		client: &http.Client{
-			Timeout: 5 * time.Second,
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				return nil
+			},
 		},
-		urlRegex: regexp.MustCompile(`https?://[^\s]+`),
+		userAgent:   cmp.Or(cfg.UserAgent, defaultUserAgent),
+		maxBytes:    cmp.Or(cfg.MaxBytes, defaultMaxBytes),
+		stripParams: stripParams,
 	}
 }
 
+// ExtractLinks finds the web addresses in content, normalized as
+// NormalizeURL does. Punctuation ending the surrounding sentence, and
+// closing brackets opened before the address, as in "(see https://go.dev)."
+// or a markdown link, are not part of it.
 func (le *LinkExtractor) ExtractLinks(content string) []string {
-	return le.urlRegex.FindAllString(content, -1)
+	var links []string
+	for _, match := range models.LinkRegex.FindAllString(content, -1) {
+		link := trimLink(match)
+		if !fetchable(link) {
+			continue
+		}
+		if link = le.NormalizeURL(link); !slices.Contains(links, link) {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// ReplaceLink rewrites each address in content that normalizes to url as
+// replacement. Punctuation around an address is kept, as ExtractLinks
+// leaves it out.
+func (le *LinkExtractor) ReplaceLink(content, url, replacement string) string {
+	return models.LinkRegex.ReplaceAllStringFunc(content, func(match string) string {
+		link := trimLink(match)
+		if !fetchable(link) || le.NormalizeURL(link) != url {
+			return match
+		}
+		return replacement + match[len(link):]
+	})
 }
 
-func (le *LinkExtractor) GetURLTitle(url string) (string, error) {
-	resp, err := le.client.Get(url)
+// trimLink drops trailing punctuation from a matched address, keeping
+// closing brackets that pair with one inside it, as in Wikipedia's
+// "Go_(programming_language)"
+func trimLink(link string) string {
+	for link != "" {
+		last := link[len(link)-1]
+		switch {
+		case strings.IndexByte(".,;:!?'*", last) >= 0:
+		case last == ')' && strings.Count(link, "(") < strings.Count(link, ")"):
+		case last == ']' && strings.Count(link, "[") < strings.Count(link, "]"):
+		case last == '}' && strings.Count(link, "{") < strings.Count(link, "}"):
+		default:
+			return link
+		}
+		link = link[:len(link)-1]
+	}
+	return link
+}
+
+// fetchable reports whether link has an http or https scheme and something
+// after it. Addresses written from "www." have none and are left as they are.
+func fetchable(link string) bool {
+	scheme, rest, ok := strings.Cut(link, "://")
+	return ok && rest != "" && (strings.EqualFold(scheme, "http") || strings.EqualFold(scheme, "https"))
+}
+
+// gone reports whether a response status means fetching again will not
+// help: client errors other than timeouts and rate limits
+func gone(status int) bool {
+	return status >= 400 && status < 500 &&
+		status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+// GetURLMetadata fetches rawURL and reads what the page says about itself.
+// OpenGraph tags are preferred over Twitter card tags, which are preferred
+// over plain <title> and description tags. Pages without a title are named
+// after their domain, and responses other than HTML, like PDFs and images,
+// after their file name without being read. Only the first MaxBytes of a
+// page are read, decoded from the charset its headers or markup declare.
+func (le *LinkExtractor) GetURLMetadata(rawURL string) (models.LinkMetadata, error) {
+	resp, err := le.request(http.MethodGet, rawURL)
 	if err != nil {
-		return "", err
+		return models.LinkMetadata{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+		if gone(resp.StatusCode) {
+			return models.LinkMetadata{}, fmt.Errorf("HTTP %d: %w", resp.StatusCode, ports.ErrLinkGone)
+		}
+		return models.LinkMetadata{}, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body := bufio.NewReader(io.LimitReader(resp.Body, le.maxBytes))
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		// Servers that do not say get sniffed, as browsers do
+		sniff, _ := body.Peek(512)
+		contentType = http.DetectContentType(sniff)
+	}
+
+	var meta models.LinkMetadata
+	meta.ContentType, _, _ = mime.ParseMediaType(contentType)
+	// The final URL after redirects, which relative links resolve against
+	base := resp.Request.URL
+
+	if meta.ContentType != "text/html" && meta.ContentType != "application/xhtml+xml" {
+		meta.Title = fileName(base)
+		if meta.Title == "" {
+			meta.Title = extractDomain(rawURL)
+		}
+		return meta, nil
 	}
 
-	doc, err := html.Parse(resp.Body)
+	// The header's charset wins, then a byte order mark, then <meta charset>
+	decoded, err := charset.NewReader(body, contentType)
 	if err != nil {
-		return "", err
+		return models.LinkMetadata{}, err
 	}
+	doc, err := html.Parse(decoded)
+	if err != nil {
+		return models.LinkMetadata{}, err
+	}
+	p := readPage(doc)
 
-	title := le.findTitle(doc)
-	if title == "" {
-		return extractDomain(url), nil
+	meta.Title = truncate(first(p.meta["og:title"], p.meta["twitter:title"], p.title), maxTitleLength)
+	if meta.Title == "" {
+		meta.Title = extractDomain(rawURL)
 	}
+	meta.Description = truncate(first(p.meta["og:description"], p.meta["twitter:description"], p.meta["description"]), maxDescriptionLength)
+	meta.SiteName = first(p.meta["og:site_name"], p.meta["application-name"])
+	if canonical := resolve(base, first(p.canonical, p.meta["og:url"])); canonical != "" {
+		meta.CanonicalURL = le.NormalizeURL(canonical)
+	}
+	meta.ImageURL = resolve(base, first(p.meta["og:image"], p.meta["twitter:image"], p.meta["twitter:image:src"]))
+	// Browsers ask for /favicon.ico when a page declares no icon
+	meta.FaviconURL = resolve(base, first(p.icon, "/favicon.ico"))
+
+	return meta, nil
+}
 
-	title = strings.TrimSpace(title)
-	if len(title) > 100 {
-		title = title[:97] + "..."
+// request sends a request for rawURL as a browser would
+func (le *LinkExtractor) request(method, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return nil, err
 	}
+	req.Header.Set("User-Agent", le.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	return le.client.Do(req)
+}
 
-	return title, nil
+// page holds the parts of an HTML document that describe it
+type page struct {
+	title     string
+	meta      map[string]string // <meta> content by lowercased property or name
+	canonical string
+	icon      string
 }
 
-func (le *LinkExtractor) findTitle(n *html.Node) string {
-	if n.Type == html.ElementNode && n.Data == "title" {
-		if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
-			return n.FirstChild.Data
+// readPage walks doc collecting its title, meta tags and link relations.
+// The first of each wins, as browsers and link previews do.
+func readPage(doc *html.Node) page {
+	p := page{meta: make(map[string]string)}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "svg":
+				// SVG images carry <title> elements of their own
+				return
+			case "title":
+				if p.title == "" {
+					p.title = textContent(n)
+				}
+			case "meta":
+				key := strings.ToLower(first(attr(n, "property"), attr(n, "name")))
+				if content := attr(n, "content"); key != "" && content != "" {
+					if _, seen := p.meta[key]; !seen {
+						p.meta[key] = content
+					}
+				}
+			case "link":
+				href := attr(n, "href")
+				rel := strings.Fields(strings.ToLower(attr(n, "rel")))
+				for _, r := range rel {
+					switch {
+					case r == "canonical" && p.canonical == "":
+						p.canonical = href
+					case r == "icon" && p.icon == "":
+						p.icon = href
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
 		}
 	}
+	walk(doc)
+
+	return p
+}
+
+// attr returns the value of n's attribute named key, or ""
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
 
+// textContent returns the text inside n
+func textContent(n *html.Node) string {
+	var text strings.Builder
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if title := le.findTitle(c); title != "" {
-			return title
+		if c.Type == html.TextNode {
+			text.WriteString(c.Data)
 		}
 	}
+	return text.String()
+}
 
+// first returns the first value that is not blank, with its whitespace
+// collapsed
+func first(values ...string) string {
+	for _, value := range values {
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			return value
+		}
+	}
 	return ""
 }
 
+// truncate cuts s to at most max characters, marking the cut with "..."
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return strings.TrimRight(string(runes[:max-3]), " ") + "..."
+}
+
+// resolve turns ref into an absolute URL relative to base, or "" when ref
+// is empty or not a web address
+func resolve(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
+
+// fileName returns the last path segment of u, or "" for a bare host
+func fileName(u *url.URL) string {
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return ""
+	}
+	return name
+}
+
 func extractDomain(url string) string {
 	domainRegex := regexp.MustCompile(`https?://([^/]+)`)
 	matches := domainRegex.FindStringSubmatch(url)
@@ -88,4 +353,4 @@ func extractDomain(url string) string {
 		return domain
 	}
 	return "Link"
-}
\ No newline at end of file
+}
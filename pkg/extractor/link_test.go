@@ -0,0 +1,288 @@
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"stak/internal/config"
+	"stak/internal/models"
+	"stak/internal/ports"
+)
+
+// newFixtureServer serves the pages in testdata, plus a PDF, a redirect
+// and a missing page
+func newFixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.Handle("/pages/", http.StripPrefix("/pages/", http.FileServer(http.Dir("testdata"))))
+	mux.HandleFunc("/docs/annual-report.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.7"))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/pages/opengraph.html", http.StatusMovedPermanently)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExtractLinks(t *testing.T) {
+	le := NewLinkExtractor()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"No links", "Plain note", nil},
+		{"Every link in order", "Compare https://go.dev/blog and http://example.com/a?b=c#d", []string{"https://go.dev/blog", "http://example.com/a?b=c#d"}},
+		{"Sentence punctuation", "Read https://go.dev/doc. Then https://go.dev/ref, maybe https://go.dev/play!", []string{"https://go.dev/doc", "https://go.dev/ref", "https://go.dev/play"}},
+		{"Wrapping parentheses", "(see https://go.dev/blog/errors)", []string{"https://go.dev/blog/errors"}},
+		{"Parentheses inside the link", "https://en.wikipedia.org/wiki/Go_(programming_language).", []string{"https://en.wikipedia.org/wiki/Go_(programming_language)"}},
+		{"Markdown link", "[the spec](https://go.dev/ref/spec) and <https://pkg.go.dev>", []string{"https://go.dev/ref/spec", "https://pkg.go.dev"}},
+		{"Quoted link", `"https://go.dev/talks", she said`, []string{"https://go.dev/talks"}},
+		{"Repeats", "https://go.dev and again https://go.dev.", []string{"https://go.dev"}},
+		{"Scheme alone", "Starts with https:// and nothing else", nil},
+		{"Addresses without a scheme", "Try www.go.dev or https://www.go.dev", []string{"https://www.go.dev"}},
+		{"Normalized repeats", "HTTPS://Go.dev/blog/?utm_source=feed and https://go.dev/blog", []string{"https://go.dev/blog"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := le.ExtractLinks(tt.content); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReplaceLink(t *testing.T) {
+	le := NewLinkExtractor()
+
+	got := le.ReplaceLink("read (https://old.example/post/?utm_source=feed). and https://other.example",
+		"https://old.example/post", "https://new.example/post")
+	if want := "read (https://new.example/post). and https://other.example"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGetURLMetadata(t *testing.T) {
+	srv := newFixtureServer(t)
+	le := NewLinkExtractor()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected models.LinkMetadata
+	}{
+		{
+			name: "OpenGraph tags win over title and description",
+			path: "/pages/opengraph.html",
+			expected: models.LinkMetadata{
+				Title:        "Error handling and Go",
+				Description:  "Go code uses error values to indicate an abnormal state.",
+				SiteName:     "The Go Programming Language",
+				CanonicalURL: srv.URL + "/blog/error-handling-and-go",
+				FaviconURL:   srv.URL + "/images/favicon.ico",
+				ImageURL:     srv.URL + "/images/gopher.png",
+				ContentType:  "text/html",
+			},
+		},
+		{
+			name: "Twitter card tags",
+			path: "/pages/twitter.html",
+			expected: models.LinkMetadata{
+				Title:        "Release notes & changelog",
+				Description:  "Everything that changed in the latest release.",
+				CanonicalURL: "https://example.com/releases/latest",
+				FaviconURL:   "https://cdn.example.com/icon.svg",
+				ImageURL:     "https://cdn.example.com/card.png",
+				ContentType:  "text/html",
+			},
+		},
+		{
+			name: "Redirects resolve links against the final page",
+			path: "/moved",
+			expected: models.LinkMetadata{
+				Title:        "Error handling and Go",
+				Description:  "Go code uses error values to indicate an abnormal state.",
+				SiteName:     "The Go Programming Language",
+				CanonicalURL: srv.URL + "/blog/error-handling-and-go",
+				FaviconURL:   srv.URL + "/images/favicon.ico",
+				ImageURL:     srv.URL + "/images/gopher.png",
+				ContentType:  "text/html",
+			},
+		},
+		{
+			name: "Untitled page is named after its domain",
+			path: "/pages/untitled.html",
+			expected: models.LinkMetadata{
+				Title:       strings.TrimPrefix(srv.URL, "http://"),
+				FaviconURL:  srv.URL + "/favicon.ico",
+				ContentType: "text/html",
+			},
+		},
+		{
+			name: "Other content types are named after the file",
+			path: "/docs/annual-report.pdf",
+			expected: models.LinkMetadata{
+				Title:       "annual-report.pdf",
+				ContentType: "application/pdf",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := le.GetURLMetadata(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetURLMetadataTruncatesByCharacter(t *testing.T) {
+	srv := newFixtureServer(t)
+
+	got, err := NewLinkExtractor().GetURLMetadata(srv.URL + "/pages/plain.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.ValidString(got.Title) {
+		t.Errorf("title was cut inside a character: %q", got.Title)
+	}
+	if n := utf8.RuneCountInString(got.Title); n > maxTitleLength {
+		t.Errorf("expected at most %d characters, got %d", maxTitleLength, n)
+	}
+	if !strings.HasPrefix(got.Title, "Über die Schönheit") || !strings.HasSuffix(got.Title, "...") {
+		t.Errorf("expected a shortened title, got %q", got.Title)
+	}
+	if got.Description != "Notizen über Unicode." {
+		t.Errorf("expected the plain description, got %q", got.Description)
+	}
+}
+
+func TestGetURLMetadataErrors(t *testing.T) {
+	srv := newFixtureServer(t)
+
+	if _, err := NewLinkExtractor().GetURLMetadata(srv.URL + "/missing"); !errors.Is(err, ports.ErrLinkGone) {
+		t.Errorf("expected a missing page to be gone for good, got %v", err)
+	}
+}
+
+func TestGetURLMetadataFetching(t *testing.T) {
+	var userAgent string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/agent", func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		w.Write([]byte("<title>Agent</title>"))
+	})
+	mux.HandleFunc("/latin1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.Write([]byte("<title>Caf\xe9 cr\xe8me</title>"))
+	})
+	mux.HandleFunc("/cyrillic", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<meta charset="windows-1251"><title>` + "\xcf\xf0\xe8\xe2\xe5\xf2" + `</title>`))
+	})
+	mux.HandleFunc("/sniffed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["Content-Type"] = nil
+		w.Write([]byte("<!DOCTYPE html><html><title>Sniffed</title></html>"))
+	})
+	mux.HandleFunc("/images/gopher.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["Content-Type"] = nil
+		w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	})
+	mux.HandleFunc("/padded", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<head><!--" + strings.Repeat("x", 4096) + "--><title>Late title</title></head>"))
+	})
+	mux.HandleFunc("/hop/", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(r.URL.Path, "/hop/%d", &n)
+		if n == 0 {
+			w.Write([]byte("<title>Landed</title>"))
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/hop/%d", n-1), http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	le := NewLinkExtractorWithConfig(config.LinksConfig{
+		UserAgent:    "stak-test/1.0",
+		MaxRedirects: 2,
+		MaxBytes:     1024,
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		title       string
+		contentType string
+	}{
+		{"Charset from the header", "/latin1", "Café crème", "text/html"},
+		{"Charset from a meta tag", "/cyrillic", "Привет", "text/html"},
+		{"Missing content type is sniffed", "/sniffed", "Sniffed", "text/html"},
+		{"Sniffed images are not parsed", "/images/gopher.png", "gopher.png", "image/png"},
+		{"Pages are read up to the size cap", "/padded", host, "text/html"},
+		{"Redirects up to the limit are followed", "/hop/2", "Landed", "text/html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := le.GetURLMetadata(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != tt.title || got.ContentType != tt.contentType {
+				t.Errorf("expected %q as %s, got %q as %s", tt.title, tt.contentType, got.Title, got.ContentType)
+			}
+		})
+	}
+
+	if _, err := le.GetURLMetadata(srv.URL + "/hop/3"); err == nil {
+		t.Error("expected too many redirects to fail")
+	}
+
+	if _, err := le.GetURLMetadata(srv.URL + "/agent"); err != nil || userAgent != "stak-test/1.0" {
+		t.Errorf("expected the configured user agent, got %q (err %v)", userAgent, err)
+	}
+	if _, err := NewLinkExtractor().GetURLMetadata(srv.URL + "/agent"); err != nil || userAgent != defaultUserAgent {
+		t.Errorf("expected the default user agent, got %q (err %v)", userAgent, err)
+	}
+	if got, _ := NewLinkExtractor().GetURLMetadata(srv.URL + "/padded"); got.Title != "Late title" {
+		t.Errorf("expected the default size cap to reach the title, got %q", got.Title)
+	}
+}
+
+func TestGetURLMetadataThroughProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte("<title>Via proxy</title>"))
+	}))
+	defer proxy.Close()
+
+	le := NewLinkExtractorWithConfig(config.LinksConfig{Proxy: proxy.URL})
+	got, err := le.GetURLMetadata("http://stak.invalid/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Via proxy" || proxied != "http://stak.invalid/page" {
+		t.Errorf("expected the request to go through the proxy, got %q for %q", got.Title, proxied)
+	}
+}
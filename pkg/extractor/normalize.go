@@ -0,0 +1,81 @@
+package extractor
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// defaultStripParams are the tracking parameters removed from links when
+// the config names none. A trailing * matches any suffix.
+var defaultStripParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"yclid",
+	"twclid",
+	"igshid",
+	"mc_cid",
+	"mc_eid",
+	"_hsenc",
+	"_hsmi",
+	"ref_src",
+}
+
+// NormalizeURL returns the form of rawURL links are stored and compared
+// in: the scheme and host lowercased, default ports and trailing slashes
+// dropped, and tracking parameters removed. Addresses that do not parse
+// are returned as they are.
+func (le *LinkExtractor) NormalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if host, port, err := net.SplitHostPort(u.Host); err == nil {
+		if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+			u.Host = host
+		}
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		stripped := false
+		for name := range query {
+			if le.tracking(name) {
+				query.Del(name)
+				stripped = true
+			}
+		}
+		// Untouched queries keep their order and escaping
+		if stripped {
+			u.RawQuery = query.Encode()
+		}
+	}
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+// tracking reports whether the query parameter name is on the strip list
+func (le *LinkExtractor) tracking(name string) bool {
+	name = strings.ToLower(name)
+	for _, param := range le.stripParams {
+		param = strings.ToLower(param)
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == param {
+			return true
+		}
+	}
+	return false
+}
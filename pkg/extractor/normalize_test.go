@@ -0,0 +1,46 @@
+package extractor
+
+import (
+	"testing"
+
+	"stak/internal/config"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	le := NewLinkExtractor()
+
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"Already normal", "https://go.dev/blog/errors", "https://go.dev/blog/errors"},
+		{"Host and scheme are lowercased", "HTTPS://Go.Dev/Blog", "https://go.dev/Blog"},
+		{"Default port", "https://go.dev:443/doc", "https://go.dev/doc"},
+		{"Other ports stay", "http://localhost:8080/doc", "http://localhost:8080/doc"},
+		{"Trailing slashes", "https://go.dev/blog/", "https://go.dev/blog"},
+		{"Bare host", "https://go.dev/", "https://go.dev"},
+		{"Tracking parameters", "https://go.dev/blog?utm_source=feed&utm_medium=rss&fbclid=x", "https://go.dev/blog"},
+		{"Other parameters stay", "https://go.dev/search?utm_source=feed&q=errors&page=2", "https://go.dev/search?page=2&q=errors"},
+		{"Untouched query keeps its order", "https://go.dev/search?q=errors&page=2", "https://go.dev/search?q=errors&page=2"},
+		{"Fragment stays", "https://go.dev/ref/spec/#Types", "https://go.dev/ref/spec#Types"},
+		{"Not a URL", "not a url", "not a url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := le.NormalizeURL(tt.url); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeURLConfiguredParams(t *testing.T) {
+	le := NewLinkExtractorWithConfig(config.LinksConfig{StripParams: []string{"ref", "share_*"}})
+
+	got := le.NormalizeURL("https://example.com/a?ref=home&share_id=1&utm_source=feed")
+	if got != "https://example.com/a?utm_source=feed" {
+		t.Errorf("expected only the configured parameters stripped, got %q", got)
+	}
+}
@@ -0,0 +1,283 @@
+package linkcache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"stak/internal/models"
+	"stak/internal/ports"
+	"stak/pkg/storage"
+)
+
+// Compile-time check to ensure Store implements LinkCachePort
+var _ ports.LinkCachePort = (*Store)(nil)
+
+const (
+	// cacheFileName holds the cache and the retry queue, in the data
+	// directory
+	cacheFileName = ".stak-links.json"
+	// DefaultTTL is how long fetched metadata is used before a link is
+	// fetched again
+	DefaultTTL = 7 * 24 * time.Hour
+	// maxAttempts is how many times a fetch is tried before it is dropped
+	maxAttempts = 10
+	// firstRetryDelay doubles with each failed attempt, up to maxRetryDelay
+	firstRetryDelay = time.Minute
+	maxRetryDelay   = 6 * time.Hour
+)
+
+// cachedLink is fetched metadata and when it was fetched
+type cachedLink struct {
+	Metadata  models.LinkMetadata `json:"metadata"`
+	FetchedAt time.Time           `json:"fetched_at"`
+}
+
+// cacheFile is the on-disk form of a Store
+type cacheFile struct {
+	Links   map[string]cachedLink `json:"links"`
+	Retries []ports.LinkRetry     `json:"retries,omitempty"`
+	// Holders maps links to the IDs of the entries holding them; nil until
+	// the index is first built
+	Holders map[string][]string `json:"holders"`
+}
+
+// Store is a link metadata cache and retry queue kept in a JSON file in the
+// data directory. Every change is written through to disk under the data
+// directory lock, on top of what other stak processes wrote meanwhile.
+type Store struct {
+	path string
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu       sync.Mutex
+	file     cacheFile
+	modified time.Time // of the file when it was last read or written
+}
+
+// New returns the store kept in dataDir, using metadata for ttl after it
+// was fetched, or DefaultTTL when ttl is zero. A missing or unreadable file
+// starts an empty store.
+func New(dataDir string, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	s := &Store{
+		path: filepath.Join(dataDir, cacheFileName),
+		ttl:  ttl,
+		now:  time.Now,
+		file: cacheFile{Links: make(map[string]cachedLink)},
+	}
+	s.reload(true)
+	return s
+}
+
+// reload reads the file again if another process changed it, or always
+// when force is set. A missing or unreadable file keeps what is held.
+func (s *Store) reload(force bool) {
+	info, err := os.Stat(s.path)
+	if err != nil || (!force && info.ModTime().Equal(s.modified)) {
+		return
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	var file cacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return
+	}
+	if file.Links == nil {
+		file.Links = make(map[string]cachedLink)
+	}
+	s.file = file
+	s.modified = info.ModTime()
+}
+
+// update applies change to the file as it is on disk and writes the result,
+// holding the data directory lock so changes from other processes are kept
+func (s *Store) update(change func(file *cacheFile)) error {
+	unlock, err := storage.LockDataDir(filepath.Dir(s.path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.reload(true)
+	change(&s.file)
+	return s.save()
+}
+
+func (s *Store) Lookup(url string) (models.LinkMetadata, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reload(false)
+	link, ok := s.file.Links[url]
+	if !ok || !s.fresh(link) {
+		return models.LinkMetadata{}, false
+	}
+	return link.Metadata, true
+}
+
+func (s *Store) Store(url string, meta models.LinkMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(file *cacheFile) {
+		file.Links[url] = cachedLink{Metadata: meta, FetchedAt: s.now()}
+	})
+}
+
+func (s *Store) Enqueue(entryID, url string, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(file *cacheFile) {
+		i := find(file.Retries, entryID, url)
+		if i < 0 {
+			file.Retries = append(file.Retries, ports.LinkRetry{EntryID: entryID, URL: url})
+			i = len(file.Retries) - 1
+		}
+
+		retry := &file.Retries[i]
+		retry.Attempts++
+		retry.LastError = err.Error()
+		retry.NextAttempt = s.now().Add(retryDelay(retry.Attempts))
+		if retry.Attempts >= maxAttempts || errors.Is(err, ports.ErrLinkGone) {
+			file.Retries = append(file.Retries[:i], file.Retries[i+1:]...)
+		}
+	})
+}
+
+func (s *Store) Due() []ports.LinkRetry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []ports.LinkRetry
+	s.update(func(file *cacheFile) {
+		now := s.now()
+		for i := range file.Retries {
+			retry := &file.Retries[i]
+			if retry.NextAttempt.After(now) {
+				continue
+			}
+			due = append(due, *retry)
+			// Hold the fetch back as if it failed again, so a second call
+			// does not start it twice; success dequeues it and failure
+			// reschedules it
+			retry.NextAttempt = now.Add(retryDelay(retry.Attempts + 1))
+		}
+	})
+	return due
+}
+
+func (s *Store) Dequeue(entryID, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(file *cacheFile) {
+		if i := find(file.Retries, entryID, url); i >= 0 {
+			file.Retries = append(file.Retries[:i], file.Retries[i+1:]...)
+		}
+	})
+}
+
+func (s *Store) Holders(url string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reload(false)
+	if s.file.Holders == nil {
+		return nil, false
+	}
+	return slices.Clone(s.file.Holders[url]), true
+}
+
+func (s *Store) Index(entryID string, urls []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(file *cacheFile) {
+		// A partial index would pass for a complete one
+		if file.Holders == nil {
+			return
+		}
+		for url, ids := range file.Holders {
+			if ids = slices.DeleteFunc(ids, func(id string) bool { return id == entryID }); len(ids) > 0 {
+				file.Holders[url] = ids
+			} else {
+				delete(file.Holders, url)
+			}
+		}
+		for _, url := range urls {
+			if !slices.Contains(file.Holders[url], entryID) {
+				file.Holders[url] = append(file.Holders[url], entryID)
+			}
+		}
+	})
+}
+
+func (s *Store) Reindex(holders map[string][]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if holders == nil {
+		holders = make(map[string][]string)
+	}
+	return s.update(func(file *cacheFile) {
+		file.Holders = holders
+	})
+}
+
+// fresh reports whether link was fetched within the TTL
+func (s *Store) fresh(link cachedLink) bool {
+	return s.now().Sub(link.FetchedAt) < s.ttl
+}
+
+// find returns the index of the queued fetch in retries, or -1
+func find(retries []ports.LinkRetry, entryID, url string) int {
+	for i, retry := range retries {
+		if retry.EntryID == entryID && retry.URL == url {
+			return i
+		}
+	}
+	return -1
+}
+
+// retryDelay is how long to wait before trying a fetch that failed
+// attempts times
+func retryDelay(attempts int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// save writes the store through a temporary file, so a crash mid-write
+// never leaves a truncated cache. Callers hold the data directory lock. Expired metadata is never used again, so
+// it is dropped rather than written.
+func (s *Store) save() error {
+	for url, link := range s.file.Links {
+		if !s.fresh(link) {
+			delete(s.file.Links, url)
+		}
+	}
+
+	data, err := json.Marshal(s.file)
+	if err != nil {
+		return err
+	}
+	if err := storage.WriteFileAtomic(s.path, data, 0644); err != nil {
+		return err
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modified = info.ModTime()
+	}
+	return nil
+}
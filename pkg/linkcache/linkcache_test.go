@@ -0,0 +1,130 @@
+package linkcache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"stak/internal/models"
+	"stak/internal/ports"
+)
+
+// newTestStore returns a store in a fresh directory whose clock is *now
+func newTestStore(t *testing.T, dir string, now *time.Time) *Store {
+	t.Helper()
+	s := New(dir, time.Hour)
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestLookupHonoursTTL(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 9, 10, 9, 0, 0, 0, time.UTC)
+	s := newTestStore(t, dir, &now)
+
+	meta := models.LinkMetadata{Title: "Go", ContentType: "text/html"}
+	if err := s.Store("https://go.dev", meta); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new store reads the cache back from disk
+	now = now.Add(59 * time.Minute)
+	reopened := newTestStore(t, dir, &now)
+	if got, ok := reopened.Lookup("https://go.dev"); !ok || got != meta {
+		t.Errorf("expected the cached metadata, got %+v (%v)", got, ok)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := reopened.Lookup("https://go.dev"); ok {
+		t.Error("expected metadata older than the TTL to be ignored")
+	}
+}
+
+func TestRetryQueue(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 9, 10, 9, 0, 0, 0, time.UTC)
+	s := newTestStore(t, dir, &now)
+	offline := errors.New("network is unreachable")
+
+	if err := s.Enqueue("a1", "https://go.dev", offline); err != nil {
+		t.Fatal(err)
+	}
+	if due := s.Due(); len(due) != 0 {
+		t.Errorf("expected nothing due before the first delay, got %v", due)
+	}
+
+	now = now.Add(firstRetryDelay)
+	reopened := newTestStore(t, dir, &now)
+	due := reopened.Due()
+	if len(due) != 1 || due[0].EntryID != "a1" || due[0].Attempts != 1 || due[0].LastError != offline.Error() {
+		t.Fatalf("expected the queued fetch to be due, got %+v", due)
+	}
+	if again := reopened.Due(); len(again) != 0 {
+		t.Errorf("expected a fetch handed out once, got %v", again)
+	}
+
+	// A second failure waits twice as long
+	reopened.Enqueue("a1", "https://go.dev", offline)
+	now = now.Add(firstRetryDelay)
+	if due := reopened.Due(); len(due) != 0 {
+		t.Errorf("expected the delay to double, got %v", due)
+	}
+	now = now.Add(firstRetryDelay)
+	if due := reopened.Due(); len(due) != 1 || due[0].Attempts != 2 {
+		t.Errorf("expected a second attempt, got %+v", due)
+	}
+
+	reopened.Dequeue("a1", "https://go.dev")
+	now = now.Add(maxRetryDelay)
+	if due := reopened.Due(); len(due) != 0 {
+		t.Errorf("expected a dequeued fetch to be gone, got %v", due)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	now := time.Date(2025, 9, 10, 9, 0, 0, 0, time.UTC)
+	s := newTestStore(t, t.TempDir(), &now)
+
+	for range maxAttempts {
+		s.Enqueue("a1", "https://gone.example", errors.New("HTTP 404"))
+	}
+	now = now.Add(maxRetryDelay)
+	if due := s.Due(); len(due) != 0 {
+		t.Errorf("expected the fetch to be dropped after %d attempts, got %v", maxAttempts, due)
+	}
+}
+
+func TestStoresShareTheFile(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 9, 10, 9, 0, 0, 0, time.UTC)
+	tui := newTestStore(t, dir, &now)
+	cli := newTestStore(t, dir, &now)
+
+	tui.Store("https://go.dev", models.LinkMetadata{Title: "Go"})
+	cli.Store("https://pkg.go.dev", models.LinkMetadata{Title: "Packages"})
+	cli.Enqueue("a1", "https://offline.example", errors.New("network is unreachable"))
+	tui.Store("https://go.dev/blog", models.LinkMetadata{Title: "Blog"})
+
+	reopened := newTestStore(t, dir, &now)
+	for _, url := range []string{"https://go.dev", "https://pkg.go.dev", "https://go.dev/blog"} {
+		if _, ok := reopened.Lookup(url); !ok {
+			t.Errorf("expected %s to survive the other store's writes", url)
+		}
+	}
+	now = now.Add(firstRetryDelay)
+	if due := reopened.Due(); len(due) != 1 {
+		t.Errorf("expected the queued fetch to survive, got %v", due)
+	}
+}
+
+func TestGoneLinksAreNotRetried(t *testing.T) {
+	now := time.Date(2025, 9, 10, 9, 0, 0, 0, time.UTC)
+	s := newTestStore(t, t.TempDir(), &now)
+
+	s.Enqueue("a1", "https://gone.example", fmt.Errorf("HTTP 404: %w", ports.ErrLinkGone))
+	now = now.Add(maxRetryDelay)
+	if due := s.Due(); len(due) != 0 {
+		t.Errorf("expected a gone link to be dropped, got %v", due)
+	}
+}
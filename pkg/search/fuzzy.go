@@ -74,12 +74,11 @@ func (f *FuzzySearcher) buildSearchText(entry models.Entry) string {
 	
 	parts = append(parts, entry.Content)
 	
-	if entry.URL != "" {
-		parts = append(parts, entry.URL)
-	}
-	
-	if entry.URLTitle != "" {
-		parts = append(parts, entry.URLTitle)
+	for _, link := range entry.Links {
+		parts = append(parts, link.URL, link.Title)
+		if link.Metadata != nil {
+			parts = append(parts, link.Metadata.SiteName, link.Metadata.Description)
+		}
 	}
 	
 	parts = append(parts, entry.Tags...)
@@ -144,12 +143,16 @@ func (f *FuzzySearcher) calculateScore(entry models.Entry, query string) int {
 		}
 	}
 
-	if entry.URL != "" && strings.Contains(strings.ToLower(entry.URL), query) {
-		score += 6
-	}
-
-	if entry.URLTitle != "" && strings.Contains(strings.ToLower(entry.URLTitle), query) {
-		score += 7
+	for _, link := range entry.Links {
+		if strings.Contains(strings.ToLower(link.URL), query) {
+			score += 6
+		}
+		if link.Title != "" && strings.Contains(strings.ToLower(link.Title), query) {
+			score += 7
+		}
+		if link.Metadata != nil && strings.Contains(strings.ToLower(link.Metadata.Description), query) {
+			score += 4
+		}
 	}
 
 	if strings.Contains(strings.ToLower(string(entry.Type)), query) {
@@ -21,8 +21,7 @@ func TestFuzzySearch(t *testing.T) {
 		{
 			ID:        "2",
 			Content:   "https://go.dev/blog/error-handling",
-			URL:       "https://go.dev/blog/error-handling",
-			URLTitle:  "Error Handling in Go",
+			Links:     []models.Link{{URL: "https://go.dev/blog/error-handling", Title: "Error Handling in Go"}},
 			Type:      models.TypeLink,
 			Tags:      []string{"golang", "link", "learning"},
 			CreatedAt: time.Now().Add(-1 * time.Hour),
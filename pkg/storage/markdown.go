@@ -0,0 +1,380 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"stak/internal/models"
+	"stak/internal/ports"
+)
+
+var (
+	checkboxRegex   = regexp.MustCompile(`^- \[([ xX-])\] ?(.*)$`)
+	tagsLineRegex   = regexp.MustCompile(`^\*Tags: (.*)\*$`)
+	attributesRegex = regexp.MustCompile(`^\*((?:Priority|Due): .*)\*$`)
+	titledLinkRegex = regexp.MustCompile(`^\[(.*)\]\((\S+)\)$`)
+	bareLinkRegex   = regexp.MustCompile(`^https?://\S+$`)
+)
+
+// dayFileFrontMatter is the YAML stored at the top of a day file. Besides the
+// entries it records a hash of each entry's rendered markdown block, so a
+// later load can tell whether the body, the front matter or both were edited.
+type dayFileFrontMatter struct {
+	models.DayFile `yaml:",inline"`
+	BodyHashes     map[string]string `yaml:"body_hashes,omitempty"`
+}
+
+// legacyFrontMatter holds the single link entries recorded before they
+// held a list of links
+type legacyFrontMatter struct {
+	Entries []struct {
+		URL      string               `yaml:"url"`
+		URLTitle string               `yaml:"url_title"`
+		Link     *models.LinkMetadata `yaml:"link"`
+	} `yaml:"entries"`
+}
+
+// migrateLinks moves the url, url_title and link fields of day files
+// written before entries held a list of links into their links. The
+// rendered body is the same either way, so body hashes still match.
+func migrateLinks(dayFile *models.DayFile, frontMatter string) error {
+	if !strings.Contains(frontMatter, "url:") {
+		return nil
+	}
+
+	var legacy legacyFrontMatter
+	if err := yaml.Unmarshal([]byte(frontMatter), &legacy); err != nil {
+		return err
+	}
+	for i, old := range legacy.Entries {
+		if i >= len(dayFile.Entries) || old.URL == "" || len(dayFile.Entries[i].Links) > 0 {
+			continue
+		}
+		dayFile.Entries[i].Links = []models.Link{{URL: old.URL, Title: old.URLTitle, Metadata: old.Link}}
+	}
+	return nil
+}
+
+// splitFrontMatter separates the YAML front matter from the markdown body.
+// The front matter must open the file and is closed by the first line that
+// is exactly "---".
+func splitFrontMatter(content string) (string, string, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return "", "", fmt.Errorf("invalid markdown file format")
+	}
+
+	rest := content[len("---\n"):]
+	if strings.HasPrefix(rest, "---\n") {
+		return "", rest[len("---\n"):], nil
+	}
+
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		if strings.HasSuffix(rest, "\n---") {
+			return rest[:len(rest)-len("\n---")], "", nil
+		}
+		return "", "", fmt.Errorf("invalid markdown file format")
+	}
+
+	return rest[:end+1], rest[end+len("\n---\n"):], nil
+}
+
+// splitBodyBlocks cuts the markdown body into one block per entry, dropping
+// the day title that precedes the first entry. Blocks start at the headings
+// of entries, looked for in front matter order, so content holding a line
+// that looks like a heading stays in its entry's block. An entry whose
+// heading is not found, as when its block was deleted, gets an empty block.
+func splitBodyBlocks(body string, entries []models.Entry) []string {
+	blocks := make([]string, len(entries))
+	var current []string
+	open := -1
+	next := 0
+
+	for _, line := range strings.Split(body, "\n") {
+		heading := strings.TrimRight(line, " \t")
+		found := slices.IndexFunc(entries[next:], func(entry models.Entry) bool {
+			return heading == entryHeading(entry)
+		})
+		if found >= 0 {
+			if open >= 0 {
+				blocks[open] = strings.Join(current, "\n")
+			}
+			current = []string{line}
+			open = next + found
+			next = open + 1
+			continue
+		}
+		if open >= 0 {
+			current = append(current, line)
+		}
+	}
+	if open >= 0 {
+		blocks[open] = strings.Join(current, "\n")
+	}
+
+	return blocks
+}
+
+// hasHeading reports whether a line of body is entry's heading
+func hasHeading(body string, entry models.Entry) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimRight(line, " \t") == entryHeading(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// entryHeading is the heading an entry's block opens with
+func entryHeading(entry models.Entry) string {
+	return "## " + entry.CreatedAt.Format("15:04:05")
+}
+
+// normalizeBlock strips trailing whitespace so editors that trim lines or
+// add a final newline do not count as edits
+func normalizeBlock(block string) string {
+	lines := strings.Split(block, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func blockHash(block string) string {
+	sum := sha256.Sum256([]byte(normalizeBlock(block)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// reconcileBody merges hand edits of the markdown body back into the entries.
+// For each entry the body block is compared with the hash recorded when the
+// file was last written: an unchanged block leaves the front matter in
+// charge, a block that alone changed is parsed back into the entry, and a
+// block and entry that both changed in different ways is a conflict. A
+// block deleted from the body deletes its entry, unless the entry changed
+// since too. Day files written before hashes were recorded treat any
+// difference as a body edit, and a missing block as a conflict.
+func (s *Storage) reconcileBody(dayFile *models.DayFile, hashes map[string]string, body string) error {
+	blocks := splitBodyBlocks(body, dayFile.Entries)
+	kept := make([]models.Entry, 0, len(dayFile.Entries))
+
+	for i, entry := range dayFile.Entries {
+		rendered := normalizeBlock(s.formatEntryAsMarkdown(entry))
+		saved, hasHash := hashes[entry.ID]
+		frontMatterChanged := hasHash && blockHash(rendered) != saved
+
+		if blocks[i] == "" {
+			// A heading still in the body was taken for another entry's
+			// content, so the block is not simply gone
+			if !hasHash || frontMatterChanged || hasHeading(body, entry) {
+				return fmt.Errorf("%w: entry %s is missing from the body",
+					ports.ErrBodyConflict, entry.ID)
+			}
+			continue
+		}
+
+		block := normalizeBlock(blocks[i])
+		bodyChanged := block != rendered && (!hasHash || blockHash(block) != saved)
+		if bodyChanged {
+			if frontMatterChanged {
+				return fmt.Errorf("%w: entry %s was edited in both the front matter and the body",
+					ports.ErrBodyConflict, entry.ID)
+			}
+			if err := applyBlock(&entry, block); err != nil {
+				return fmt.Errorf("%w: entry %s: %v", ports.ErrBodyConflict, entry.ID, err)
+			}
+		}
+		kept = append(kept, entry)
+	}
+
+	dayFile.Entries = kept
+	return nil
+}
+
+// applyBlock parses a rendered entry block back into entry. Only the parts
+// the renderer writes are read back: content, checkbox state, links and tags.
+// Edited tags are recorded as chosen by hand.
+func applyBlock(entry *models.Entry, block string) error {
+	lines := strings.Split(block, "\n")
+	if strings.TrimSpace(lines[0]) != entryHeading(*entry) {
+		return fmt.Errorf("heading changed to %q", lines[0])
+	}
+	lines = lines[1:]
+
+	// Drop the entry separator and surrounding blank lines
+	lines = trimBlankLines(lines)
+	if len(lines) > 0 && lines[len(lines)-1] == "---" {
+		lines = trimBlankLines(lines[:len(lines)-1])
+	}
+
+	var tags []string
+	if n := len(lines); n > 0 {
+		if match := tagsLineRegex.FindStringSubmatch(lines[n-1]); match != nil {
+			for _, tag := range strings.Split(match[1], ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+			lines = trimBlankLines(lines[:n-1])
+		}
+	}
+
+	attrs := *entry
+	attrs.Priority, attrs.DueAt = "", nil
+	if n := len(lines); n > 0 {
+		if match := attributesRegex.FindStringSubmatch(lines[n-1]); match != nil {
+			if err := parseAttributes(&attrs, match[1]); err != nil {
+				return err
+			}
+			lines = trimBlankLines(lines[:n-1])
+		}
+	}
+
+	links := entry.Links
+	// The link lines are only rendered for entries with links and always
+	// follow the content as a paragraph of their own, one link per line
+	if len(entry.Links) > 0 {
+		start := len(lines)
+		for start > 0 && (titledLinkRegex.MatchString(lines[start-1]) || bareLinkRegex.MatchString(lines[start-1])) {
+			start--
+		}
+		if start >= 2 && start < len(lines) && lines[start-1] == "" {
+			links = parseLinks(entry.Links, lines[start:])
+			lines = trimBlankLines(lines[:start])
+		}
+	}
+
+	if len(lines) == 0 {
+		return fmt.Errorf("content was removed")
+	}
+
+	status := entry.TodoStatus
+	if entry.Type == models.TypeTodo {
+		match := checkboxRegex.FindStringSubmatch(lines[0])
+		if match == nil {
+			return fmt.Errorf("todo checkbox was removed")
+		}
+		switch match[1] {
+		case " ":
+			status = models.TodoPending
+		case "-":
+			status = models.TodoCancelled
+		default:
+			status = models.TodoCompleted
+		}
+		lines[0] = match[2]
+	}
+
+	entry.Content = strings.Join(lines, "\n")
+	if status != entry.TodoStatus {
+		entry.SetTodoStatus(status, time.Now())
+	}
+	entry.Priority = attrs.Priority
+	entry.DueAt = attrs.DueAt
+	entry.Links = links
+	if !slices.Equal(tags, entry.Tags) {
+		entry.MarkTagsEditedByHand()
+	}
+	entry.Tags = tags
+	return nil
+}
+
+// parseLinks reads rendered link lines back into links. A link keeps the
+// metadata fetched for it and its last check when its address is
+// unchanged; those of an address that was replaced described the old page
+// and are dropped.
+func parseLinks(previous []models.Link, lines []string) []models.Link {
+	links := make([]models.Link, 0, len(lines))
+	for _, line := range lines {
+		link := models.Link{URL: line}
+		if match := titledLinkRegex.FindStringSubmatch(line); match != nil {
+			link = models.Link{URL: match[2], Title: match[1]}
+		}
+		for _, old := range previous {
+			if old.URL == link.URL {
+				link.Metadata, link.Check = old.Metadata, old.Check
+				break
+			}
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// attributeSeparator joins the parts of the priority and due date line
+const attributeSeparator = " · "
+
+// formatAttributes renders a todo's priority and due date for the body, or
+// "" when neither is set
+func formatAttributes(entry models.Entry) string {
+	var parts []string
+	if entry.Priority != "" {
+		parts = append(parts, "Priority: "+string(entry.Priority))
+	}
+	if entry.DueAt != nil {
+		parts = append(parts, "Due: "+entry.FormatDue())
+	}
+	return strings.Join(parts, attributeSeparator)
+}
+
+// parseAttributes reads a line written by formatAttributes back into entry
+func parseAttributes(entry *models.Entry, line string) error {
+	var priority models.Priority
+	var due *time.Time
+
+	for _, part := range strings.Split(line, attributeSeparator) {
+		name, value, _ := strings.Cut(part, ": ")
+		value = strings.TrimSpace(value)
+		switch name {
+		case "Priority":
+			p, ok := models.ParsePriority(value)
+			if !ok {
+				return fmt.Errorf("unknown priority %q", value)
+			}
+			priority = p
+		case "Due":
+			d, err := parseDue(value, entry.DueAt)
+			if err != nil {
+				return err
+			}
+			due = &d
+		default:
+			return fmt.Errorf("unknown attribute %q", part)
+		}
+	}
+
+	entry.Priority = priority
+	entry.DueAt = due
+	return nil
+}
+
+// parseDue reads a due date in the location of the existing one, so an
+// untouched date round-trips exactly
+func parseDue(value string, existing *time.Time) (time.Time, error) {
+	loc := time.Local
+	if existing != nil {
+		loc = existing.Location()
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		if due, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return due, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid due date %q", value)
+}
+
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,487 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"stak/internal/models"
+	"stak/internal/ports"
+)
+
+// editDayFile rewrites the day file holding date by replacing old with new
+func editDayFile(t *testing.T, s *Storage, date time.Time, old, new string) {
+	t.Helper()
+
+	path := s.dayFilePath(date.Format(s.config.DateFormat))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read day file: %v", err)
+	}
+	if !strings.Contains(string(content), old) {
+		t.Fatalf("day file does not contain %q:\n%s", old, content)
+	}
+	edited := strings.Replace(string(content), old, new, 1)
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatalf("failed to write day file: %v", err)
+	}
+}
+
+func saveTodoOn(t *testing.T, s *Storage, content string, date time.Time) *models.Entry {
+	t.Helper()
+
+	entry := models.NewEntry(content)
+	entry.ID = content
+	entry.Type = models.TypeTodo
+	entry.TodoStatus = models.TodoPending
+	entry.Tags = []string{"todo", "task"}
+	entry.CreatedAt = date
+	entry.UpdatedAt = date
+	if err := s.SaveEntry(entry); err != nil {
+		t.Fatalf("failed to save todo %q: %v", content, err)
+	}
+	return entry
+}
+
+func TestBodyEditsAreReconciled(t *testing.T) {
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name   string
+		old    string
+		new    string
+		verify func(t *testing.T, entry models.Entry)
+	}{
+		{
+			name: "Ticked checkbox completes the todo",
+			old:  "- [ ] buy milk",
+			new:  "- [x] buy milk",
+			verify: func(t *testing.T, entry models.Entry) {
+				if entry.TodoStatus != models.TodoCompleted {
+					t.Errorf("expected completed todo, got %v", entry.TodoStatus)
+				}
+			},
+		},
+		{
+			name: "Dashed checkbox cancels the todo",
+			old:  "- [ ] buy milk",
+			new:  "- [-] buy milk",
+			verify: func(t *testing.T, entry models.Entry) {
+				if entry.TodoStatus != models.TodoCancelled || entry.Content != "buy milk" {
+					t.Errorf("expected cancelled todo, got %v %q", entry.TodoStatus, entry.Content)
+				}
+			},
+		},
+		{
+			name: "Typo fix updates the content",
+			old:  "- [ ] buy milk",
+			new:  "- [ ] buy oat milk",
+			verify: func(t *testing.T, entry models.Entry) {
+				if entry.Content != "buy oat milk" {
+					t.Errorf("expected edited content, got %q", entry.Content)
+				}
+			},
+		},
+		{
+			name: "Edited tags line replaces the tags",
+			old:  "*Tags: todo, task*",
+			new:  "*Tags: todo, groceries*",
+			verify: func(t *testing.T, entry models.Entry) {
+				if strings.Join(entry.Tags, ",") != "todo,groceries" {
+					t.Errorf("expected edited tags, got %v", entry.Tags)
+				}
+				if !entry.TagsEditedByHand() {
+					t.Errorf("expected the tags to be recorded as edited by hand")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			saveTodoOn(t, s, "buy milk", day)
+			other := saveTodoOn(t, s, "call bob", day.Add(time.Minute))
+
+			editDayFile(t, s, day, tt.old, tt.new)
+
+			// An unrelated save must keep the hand edit
+			other.TodoStatus = models.TodoCompleted
+			if err := s.SaveEntry(other); err != nil {
+				t.Fatalf("save after body edit failed: %v", err)
+			}
+
+			entries, err := s.LoadEntriesForDate(day)
+			if err != nil || len(entries) != 2 {
+				t.Fatalf("expected 2 entries, got %d (err %v)", len(entries), err)
+			}
+			tt.verify(t, entries[0])
+			if entries[1].TodoStatus != models.TodoCompleted {
+				t.Errorf("expected the saved entry to keep its own change")
+			}
+		})
+	}
+}
+
+func TestFrontMatterEditIsKept(t *testing.T) {
+	s := newTestStorage(t)
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+	saveTodoOn(t, s, "buy milk", day)
+
+	editDayFile(t, s, day, "content: buy milk", "content: buy bread")
+
+	entries, _ := s.LoadEntriesForDate(day)
+	if len(entries) != 1 || entries[0].Content != "buy bread" {
+		t.Fatalf("expected the front matter edit to win over an untouched body, got %v", entries)
+	}
+}
+
+func TestBodyConflicts(t *testing.T) {
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name  string
+		edits [][2]string
+	}{
+		{
+			name: "Both sides edited differently",
+			edits: [][2]string{
+				{"content: buy milk", "content: buy bread"},
+				{"- [ ] buy milk", "- [ ] buy cheese"},
+			},
+		},
+		{
+			name: "Entry section removed from the body after a front matter edit",
+			edits: [][2]string{
+				{"content: buy milk", "content: buy bread"},
+				{"## 09:30:00\n\n- [ ] buy milk\n\n*Tags: todo, task*\n\n---\n\n", ""},
+			},
+		},
+		{
+			name: "Todo checkbox removed",
+			edits: [][2]string{
+				{"- [ ] buy milk", "buy milk"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			saveTodoOn(t, s, "buy milk", day)
+			other := saveTodoOn(t, s, "call bob", day.Add(time.Minute))
+
+			for _, edit := range tt.edits {
+				editDayFile(t, s, day, edit[0], edit[1])
+			}
+			path := s.dayFilePath(day.Format(s.config.DateFormat))
+			before, _ := os.ReadFile(path)
+
+			if err := s.SaveEntry(other); !errors.Is(err, ports.ErrBodyConflict) {
+				t.Fatalf("expected ErrBodyConflict, got %v", err)
+			}
+
+			after, _ := os.ReadFile(path)
+			if string(before) != string(after) {
+				t.Errorf("expected the conflicting file to be left untouched")
+			}
+
+			// A read alone reports the conflict
+			reader := New(s.config)
+			if entries, err := reader.LoadEntriesForDate(day); err != nil || len(entries) != 2 {
+				t.Errorf("expected reads to fall back to the front matter, got %d entries (err %v)", len(entries), err)
+			}
+			if conflicts := reader.Conflicts(); len(conflicts) != 1 || !errors.Is(conflicts[0], ports.ErrBodyConflict) {
+				t.Errorf("expected the conflict to be reported on load, got %v", conflicts)
+			}
+		})
+	}
+}
+
+func TestDeletedBlockDeletesEntry(t *testing.T) {
+	s := newTestStorage(t)
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+	saveTodoOn(t, s, "buy milk", day)
+	other := saveTodoOn(t, s, "call bob", day.Add(time.Minute))
+
+	editDayFile(t, s, day, "## 09:30:00\n\n- [ ] buy milk\n\n*Tags: todo, task*\n\n---\n\n", "")
+
+	other.TodoStatus = models.TodoCompleted
+	if err := s.SaveEntry(other); err != nil {
+		t.Fatalf("save after deleting a block failed: %v", err)
+	}
+
+	entries, err := s.LoadEntriesForDate(day)
+	if err != nil || len(entries) != 1 || entries[0].ID != "call bob" {
+		t.Fatalf("expected only the kept entry, got %v (err %v)", entries, err)
+	}
+	content, _ := os.ReadFile(s.dayFilePath(day.Format(s.config.DateFormat)))
+	if strings.Contains(string(content), "buy milk") {
+		t.Errorf("expected the deleted entry to leave the front matter too:\n%s", content)
+	}
+	if conflicts := s.Conflicts(); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %v", conflicts)
+	}
+}
+
+func TestResolveConflicts(t *testing.T) {
+	s := newTestStorage(t)
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+	saveTodoOn(t, s, "buy milk", day)
+	other := saveTodoOn(t, s, "call bob", day.Add(time.Minute))
+
+	editDayFile(t, s, day, "content: buy milk", "content: buy bread")
+	editDayFile(t, s, day, "- [ ] buy milk", "- [ ] buy cheese")
+	path := s.dayFilePath(day.Format(s.config.DateFormat))
+	edited, _ := os.ReadFile(path)
+
+	backups, err := s.ResolveConflicts()
+	if err != nil || len(backups) != 1 || backups[0] != path+".bak" {
+		t.Fatalf("expected one backup beside the day file, got %v (err %v)", backups, err)
+	}
+	if kept, _ := os.ReadFile(backups[0]); string(kept) != string(edited) {
+		t.Errorf("expected the backup to hold the edited file")
+	}
+
+	entries, _ := s.LoadEntriesForDate(day)
+	if len(entries) != 2 || entries[0].Content != "buy bread" {
+		t.Fatalf("expected the front matter to win, got %v", entries)
+	}
+	if conflicts := s.Conflicts(); len(conflicts) != 0 {
+		t.Errorf("expected the conflict to be gone, got %v", conflicts)
+	}
+	if err := s.SaveEntry(other); err != nil {
+		t.Errorf("expected the day file to be writable again, got %v", err)
+	}
+
+	// Nothing left to resolve
+	if backups, err := s.ResolveConflicts(); err != nil || len(backups) != 0 {
+		t.Errorf("expected nothing to resolve, got %v (err %v)", backups, err)
+	}
+}
+
+func TestHeadingLikeContentStaysInItsEntry(t *testing.T) {
+	s := newTestStorage(t)
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+
+	note := models.NewEntry("standup notes\n## 12:34:56\ndeploy went out")
+	note.ID = "note"
+	note.CreatedAt = day
+	note.UpdatedAt = day
+	if err := s.SaveEntry(note); err != nil {
+		t.Fatalf("failed to save note: %v", err)
+	}
+	other := saveTodoOn(t, s, "call bob", day.Add(time.Minute))
+
+	other.TodoStatus = models.TodoCompleted
+	if err := s.SaveEntry(other); err != nil {
+		t.Fatalf("expected the day file to stay writable, got %v", err)
+	}
+
+	entries, err := s.LoadEntriesForDate(day)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d (err %v)", len(entries), err)
+	}
+	if entries[0].Content != note.Content {
+		t.Errorf("expected the note content to be kept, got %q", entries[0].Content)
+	}
+	if entries[1].TodoStatus != models.TodoCompleted {
+		t.Errorf("expected the todo to be saved as completed")
+	}
+}
+
+func TestLegacyDayFileWithoutHashes(t *testing.T) {
+	s := newTestStorage(t)
+	day := time.Date(2025, 9, 7, 13, 5, 52, 0, time.Local)
+
+	legacy := `---
+date: 2025-09-07T13:05:52+08:00
+entries:
+    - id: 20250907130552-aa2222
+      content: need to fix the
+      type: todo
+      tags:
+        - todo
+        - task
+      todo_status: pending
+      created_at: ` + day.Format(time.RFC3339Nano) + `
+      updated_at: ` + day.Format(time.RFC3339Nano) + `
+---
+
+# September 7, 2025
+
+## 13:05:52
+
+- [x] need to fix the
+
+*Tags: todo, task*
+
+---
+
+`
+	path := filepath.Join(s.config.DataDir, "2025-09-07.md")
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.LoadEntriesForDate(day)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d (err %v)", len(entries), err)
+	}
+	if entries[0].TodoStatus != models.TodoCompleted {
+		t.Errorf("expected the ticked checkbox to be read back, got %v", entries[0].TodoStatus)
+	}
+}
+
+func TestTodoAttributesRoundTrip(t *testing.T) {
+	s := newTestStorage(t)
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+	due := time.Date(2025, 9, 12, 0, 0, 0, 0, time.Local)
+
+	entry := models.NewEntry("file taxes")
+	entry.ID = "file taxes"
+	entry.Type = models.TypeTodo
+	entry.TodoStatus = models.TodoPending
+	entry.Priority = models.PriorityHigh
+	entry.DueAt = &due
+	entry.CreatedAt = day
+	if err := s.SaveEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	editDayFile(t, s, day, "*Priority: high · Due: 2025-09-12*", "*Priority: low · Due: 2025-09-15 17:00*")
+	editDayFile(t, s, day, "- [ ] file taxes", "- [x] file taxes")
+
+	entries, err := s.LoadEntriesForDate(day)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d (err %v)", len(entries), err)
+	}
+	got := entries[0]
+	if got.Priority != models.PriorityLow {
+		t.Errorf("expected the edited priority, got %q", got.Priority)
+	}
+	if got.FormatDue() != "2025-09-15 17:00" {
+		t.Errorf("expected the edited due date, got %q", got.FormatDue())
+	}
+	if got.TodoStatus != models.TodoCompleted || got.CompletedAt == nil {
+		t.Errorf("expected a completed todo with a completion time, got %v %v", got.TodoStatus, got.CompletedAt)
+	}
+}
+
+func TestCancelledTodoRoundTrip(t *testing.T) {
+	s := newTestStorage(t)
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+
+	entry := saveTodoOn(t, s, "renew passport", day)
+	entry.SetTodoStatus(models.TodoCancelled, day)
+	if err := s.SaveEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	// Clearing the dash reopens the todo
+	editDayFile(t, s, day, "- [-] renew passport", "- [ ] renew passport")
+	entries, err := s.LoadEntriesForDate(day)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d (err %v)", len(entries), err)
+	}
+	if entries[0].TodoStatus != models.TodoPending {
+		t.Errorf("expected the todo to be reopened, got %v", entries[0].TodoStatus)
+	}
+}
+
+func TestLinksRoundTrip(t *testing.T) {
+	s := newTestStorage(t)
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+
+	entry := models.NewEntry("compare https://go.dev and https://pkg.go.dev")
+	entry.ID = "links"
+	entry.Type = models.TypeLink
+	entry.Links = []models.Link{
+		{URL: "https://go.dev", Title: "Go", Metadata: &models.LinkMetadata{Title: "Go", SiteName: "go.dev"}},
+		{URL: "https://pkg.go.dev"},
+	}
+	entry.CreatedAt = day
+	entry.UpdatedAt = day
+	if err := s.SaveEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every link is listed, one per line, and a retitled link keeps its metadata
+	editDayFile(t, s, day, "\n\n[Go](https://go.dev)\nhttps://pkg.go.dev\n", "\n\n[The Go site](https://go.dev)\n[Packages](https://pkg.go.dev)\n")
+	entries, err := s.LoadEntriesForDate(day)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d (err %v)", len(entries), err)
+	}
+	links := entries[0].Links
+	if len(links) != 2 || links[0].Title != "The Go site" || links[0].Metadata == nil || links[1].Title != "Packages" {
+		t.Fatalf("expected both links retitled, got %+v", links)
+	}
+	if entries[0].Content != "compare https://go.dev and https://pkg.go.dev" {
+		t.Errorf("expected the content without the links, got %q", entries[0].Content)
+	}
+
+	// A changed address drops the metadata of the page it replaced
+	editDayFile(t, s, day, "[The Go site](https://go.dev)", "https://go.dev/doc")
+	entries, _ = s.LoadEntriesForDate(day)
+	if links := entries[0].Links; len(links) != 2 || links[0].URL != "https://go.dev/doc" || links[0].Metadata != nil {
+		t.Errorf("expected the replaced link without metadata, got %+v", links)
+	}
+}
+
+func TestLegacySingleLinkMigrates(t *testing.T) {
+	s := newTestStorage(t)
+	day := time.Date(2025, 9, 7, 13, 5, 52, 0, time.Local)
+
+	legacy := `---
+date: 2025-09-07T13:05:52+08:00
+entries:
+    - id: 20250907130552-bb3333
+      content: read https://go.dev/blog
+      type: link
+      url: https://go.dev/blog
+      url_title: The Go Blog
+      link:
+        title: The Go Blog
+        site_name: go.dev
+      created_at: ` + day.Format(time.RFC3339Nano) + `
+      updated_at: ` + day.Format(time.RFC3339Nano) + `
+---
+
+# September 7, 2025
+
+## 13:05:52
+
+read https://go.dev/blog
+
+[The Go Blog](https://go.dev/blog)
+
+---
+
+`
+	path := filepath.Join(s.config.DataDir, "2025-09-07.md")
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.LoadEntriesForDate(day)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d (err %v)", len(entries), err)
+	}
+	links := entries[0].Links
+	if len(links) != 1 || links[0].URL != "https://go.dev/blog" || links[0].Title != "The Go Blog" ||
+		links[0].Metadata == nil || links[0].Metadata.SiteName != "go.dev" {
+		t.Fatalf("expected the single link to migrate, got %+v", links)
+	}
+
+	// The next save writes the list in place of the old fields
+	if err := s.SaveEntry(&entries[0]); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "links:") || strings.Contains(string(content), "url_title:") {
+		t.Errorf("expected the day file to be rewritten with links:\n%s", content)
+	}
+}
@@ -1,10 +1,14 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -13,8 +17,21 @@ import (
 	"stak/internal/ports"
 )
 
+const (
+	// trashDirName holds deleted entries so they can be restored
+	trashDirName = ".trash"
+	// archiveDirName holds entries hidden from the normal views
+	archiveDirName = "archive"
+)
+
 type Storage struct {
 	config *config.Config
+	// writeMu serializes day file writes from this process; lockDataDir adds
+	// an advisory lock so other stak processes are serialized too
+	writeMu sync.Mutex
+
+	conflictMu sync.Mutex
+	conflicts  map[string]error // body conflicts by day file path
 }
 
 // Compile-time check to ensure Storage implements StoragePort
@@ -31,14 +48,21 @@ func (s *Storage) Initialize() error {
 }
 
 func (s *Storage) SaveEntry(entry *models.Entry) error {
+	return s.saveEntryOnDay(entry, entry.CreatedAt)
+}
 
-date := entry.CreatedAt.Format(s.config.DateFormat)
-dayFile, err := s.loadDayFile(date)
+// saveEntryOnDay inserts or replaces entry in the day file for day
+func (s *Storage) saveEntryOnDay(entry *models.Entry, day time.Time) error {
+	unlock, err := s.lockDataDir()
 	if err != nil {
-		dayFile = &models.DayFile{
-			Date:    entry.CreatedAt,
-			Entries: []models.Entry{},
-		}
+		return err
+	}
+	defer unlock()
+
+	date := day.Format(s.config.DateFormat)
+	dayFile, err := s.loadDayFileForWrite(s.dayFilePath(date), day)
+	if err != nil {
+		return err
 	}
 
 	found := false
@@ -57,16 +81,83 @@ dayFile, err := s.loadDayFile(date)
 	return s.saveDayFile(date, dayFile)
 }
 
+// LoadEntry finds a single entry by ID in the day files
+func (s *Storage) LoadEntry(id string) (*models.Entry, error) {
+	_, dayFile, idx, err := s.locateEntry(s.config.DataDir, id)
+	if err != nil {
+		return nil, err
+	}
+	entry := dayFile.Entries[idx]
+	return &entry, nil
+}
+
 func (s *Storage) LoadTodayEntries() ([]models.Entry, error) {
+	return s.LoadEntriesForDate(time.Now())
+}
 
-today := time.Now().Format(s.config.DateFormat)
-dayFile, err := s.loadDayFile(today)
+// LoadEntriesForDate reads the single day file for date. A missing day file
+// is not an error, it just means nothing was captured that day.
+func (s *Storage) LoadEntriesForDate(date time.Time) ([]models.Entry, error) {
+	dayFile, err := s.loadDayFile(date.Format(s.config.DateFormat))
 	if err != nil {
 		return []models.Entry{}, nil
 	}
 	return dayFile.Entries, nil
 }
 
+// LoadEntriesBetween returns the entries of every day file from start to end,
+// both days inclusive; a zero end leaves the range open. Only the day files
+// inside the range are opened.
+func (s *Storage) LoadEntriesBetween(start, end time.Time) ([]models.Entry, error) {
+	startDay := truncateToDay(start)
+	endDay := truncateToDay(end)
+	if !end.IsZero() && endDay.Before(startDay) {
+		return []models.Entry{}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.config.DataDir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Glob returns names in lexical order, which is date order for the
+	// default date format; sort on the parsed date so any format works
+	type datedFile struct {
+		day  time.Time
+		path string
+	}
+	var inRange []datedFile
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".md")
+		day, err := time.ParseInLocation(s.config.DateFormat, name, start.Location())
+		if err != nil {
+			continue // Not a day file
+		}
+		if day.Before(startDay) || (!end.IsZero() && day.After(endDay)) {
+			continue
+		}
+		inRange = append(inRange, datedFile{day: day, path: file})
+	}
+	sort.Slice(inRange, func(i, j int) bool {
+		return inRange[i].day.Before(inRange[j].day)
+	})
+
+	entries := []models.Entry{}
+	for _, file := range inRange {
+		dayFile, err := s.readDayFile(file.path)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, dayFile.Entries...)
+	}
+
+	return entries, nil
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
 func (s *Storage) LoadFilteredEntries(entryType models.EntryType) ([]models.Entry, error) {
 	allEntries, err := s.LoadAllEntries()
 	if err != nil {
@@ -92,7 +183,7 @@ func (s *Storage) LoadAllEntries() ([]models.Entry, error) {
 	}
 
 	for _, file := range files {
-		dayFile, err := s.loadDayFileFromPath(file)
+		dayFile, err := s.readDayFile(file)
 		if err != nil {
 			continue
 		}
@@ -109,15 +200,20 @@ func (s *Storage) SearchEntries(query string, linksOnly bool) ([]models.Entry, e
 	}
 
 	var results []models.Entry
-	queryLower := strings.ToLower(query)
+	// #tag, @person and +project terms filter on those fields exactly
+	text, tokens := models.SplitInlineTokens(query)
+	queryLower := strings.ToLower(text)
 
 	for _, entry := range allEntries {
 		// If linksOnly is true, only search link entries
 		if linksOnly && entry.Type != models.TypeLink {
 			continue
 		}
-		
-		if s.matchesQuery(entry, queryLower) {
+
+		if !entry.HasInlineTokens(tokens) {
+			continue
+		}
+		if queryLower == "" || s.matchesQuery(entry, queryLower) {
 			results = append(results, entry)
 		}
 	}
@@ -143,9 +239,12 @@ func (s *Storage) SearchLinks(query string) ([]models.Entry, error) {
 	return results, nil
 }
 
+func (s *Storage) dayFilePath(date string) string {
+	return filepath.Join(s.config.DataDir, date+".md")
+}
+
 func (s *Storage) loadDayFile(date string) (*models.DayFile, error) {
-	filePath := filepath.Join(s.config.DataDir, date+".md")
-	return s.loadDayFileFromPath(filePath)
+	return s.readDayFile(s.dayFilePath(date))
 }
 
 func (s *Storage) loadDayFileFromPath(filePath string) (*models.DayFile, error) {
@@ -154,59 +253,248 @@ func (s *Storage) loadDayFileFromPath(filePath string) (*models.DayFile, error)
 		return nil, err
 	}
 
-	parts := strings.SplitN(string(content), "---", 3)
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("invalid markdown file format")
+	frontMatter, body, err := splitFrontMatter(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed dayFileFrontMatter
+	if err := yaml.Unmarshal([]byte(frontMatter), &parsed); err != nil {
+		return nil, err
+	}
+	if err := migrateLinks(&parsed.DayFile, frontMatter); err != nil {
+		return nil, err
+	}
+
+	// Hand edits to the body are merged back into the entries. On a
+	// conflict the front matter version is still returned for reading, but
+	// callers that write must not save over the file.
+	dayFile := parsed.DayFile
+	reconciled := parsed.DayFile
+	reconciled.Entries = append([]models.Entry(nil), parsed.Entries...)
+	if err := s.reconcileBody(&reconciled, parsed.BodyHashes, body); err != nil {
+		err = fmt.Errorf("%s: %w", filepath.Base(filePath), err)
+		s.noteConflict(filePath, err)
+		return &dayFile, err
+	}
+
+	s.noteConflict(filePath, nil)
+	return &reconciled, nil
+}
+
+// noteConflict records the body conflict of the day file at filePath, or
+// forgets it when err is nil
+func (s *Storage) noteConflict(filePath string, err error) {
+	s.conflictMu.Lock()
+	defer s.conflictMu.Unlock()
+
+	if err == nil {
+		delete(s.conflicts, filePath)
+		return
+	}
+	if s.conflicts == nil {
+		s.conflicts = make(map[string]error)
+	}
+	s.conflicts[filePath] = err
+}
+
+// Conflicts returns the body conflicts found while loading day files, in
+// file order
+func (s *Storage) Conflicts() []error {
+	s.conflictMu.Lock()
+	defer s.conflictMu.Unlock()
+
+	paths := make([]string, 0, len(s.conflicts))
+	for path := range s.conflicts {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
 
-	var dayFile models.DayFile
-	if err := yaml.Unmarshal([]byte(parts[1]), &dayFile); err != nil {
+	conflicts := make([]error, len(paths))
+	for i, path := range paths {
+		conflicts[i] = s.conflicts[path]
+	}
+	return conflicts
+}
+
+// ResolveConflicts settles every body conflict in favour of the front
+// matter. Each conflicting day file, trashed and archived ones included, is
+// first copied to a .bak file beside it so no hand edit is lost, then
+// written again from its front matter.
+func (s *Storage) ResolveConflicts() ([]string, error) {
+	unlock, err := s.lockDataDir()
+	if err != nil {
 		return nil, err
 	}
+	defer unlock()
 
-	return &dayFile, nil
+	var backups []string
+	for _, dir := range []string{s.config.DataDir, s.archiveDir(), s.trashDir()} {
+		files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+		if err != nil {
+			return backups, err
+		}
+		for _, file := range files {
+			dayFile, err := s.loadDayFileFromPath(file)
+			if !errors.Is(err, ports.ErrBodyConflict) {
+				continue
+			}
+			backup, err := backupFile(file)
+			if err != nil {
+				return backups, err
+			}
+			backups = append(backups, backup)
+			if err := s.saveDayFileToPath(file, dayFile); err != nil {
+				return backups, err
+			}
+		}
+	}
+	return backups, nil
+}
+
+// backupFile copies the file at path to path.bak, or to path.2.bak and so
+// on when earlier copies exist, and returns the copy's path
+func backupFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	backup := path + ".bak"
+	for n := 2; ; n++ {
+		if _, err := os.Stat(backup); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		backup = fmt.Sprintf("%s.%d.bak", path, n)
+	}
+	return backup, WriteFileAtomic(backup, data, 0644)
+}
+
+// readDayFile loads a day file for reading only, tolerating body conflicts
+// by falling back to the front matter
+func (s *Storage) readDayFile(filePath string) (*models.DayFile, error) {
+	dayFile, err := s.loadDayFileFromPath(filePath)
+	if err != nil && dayFile != nil && errors.Is(err, ports.ErrBodyConflict) {
+		return dayFile, nil
+	}
+	return dayFile, err
+}
+
+// loadDayFileForWrite loads a day file that is about to be modified. A
+// missing file yields an empty day; any other error, including a body
+// conflict, is returned so the file is never saved over.
+func (s *Storage) loadDayFileForWrite(filePath string, date time.Time) (*models.DayFile, error) {
+	dayFile, err := s.loadDayFileFromPath(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &models.DayFile{
+			Date:    date,
+			Entries: []models.Entry{},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dayFile, nil
 }
 
 func (s *Storage) saveDayFile(date string, dayFile *models.DayFile) error {
-	filePath := filepath.Join(s.config.DataDir, date+".md")
-	
-	yamlData, err := yaml.Marshal(dayFile)
+	return s.saveDayFileToPath(s.dayFilePath(date), dayFile)
+}
+
+func (s *Storage) saveDayFileToPath(filePath string, dayFile *models.DayFile) error {
+	var body strings.Builder
+	hashes := make(map[string]string, len(dayFile.Entries))
+	for _, entry := range dayFile.Entries {
+		block := s.formatEntryAsMarkdown(entry)
+		hashes[entry.ID] = blockHash(block)
+		body.WriteString(block)
+	}
+
+	yamlData, err := yaml.Marshal(dayFileFrontMatter{DayFile: *dayFile, BodyHashes: hashes})
 	if err != nil {
 		return err
 	}
 
 	content := fmt.Sprintf("---\n%s---\n\n# %s\n\n", string(yamlData), dayFile.Date.Format("January 2, 2006"))
-	
-	for _, entry := range dayFile.Entries {
-		content += s.formatEntryAsMarkdown(entry)
+	content += body.String()
+
+	if err := WriteFileAtomic(filePath, []byte(content), 0644); err != nil {
+		return err
+	}
+	s.noteConflict(filePath, nil)
+	return nil
+}
+
+// WriteFileAtomic writes data to a temp file next to path and renames it into
+// place, so readers and editors never see a half written file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// Clean up the temp file on any failure before the rename
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
 	}
 
-	return os.WriteFile(filePath, []byte(content), 0644)
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
 }
 
 func (s *Storage) formatEntryAsMarkdown(entry models.Entry) string {
 	var md strings.Builder
 	
-	md.WriteString(fmt.Sprintf("## %s\n\n", entry.CreatedAt.Format("15:04:05")))
+	md.WriteString(entryHeading(entry) + "\n\n")
 	
 	if entry.Type == models.TypeTodo {
 		checkbox := "[ ]"
-		if entry.TodoStatus == models.TodoCompleted {
+		switch entry.TodoStatus {
+		case models.TodoCompleted:
 			checkbox = "[x]"
+		case models.TodoCancelled:
+			checkbox = "[-]"
 		}
 		md.WriteString(fmt.Sprintf("- %s %s\n", checkbox, entry.Content))
 	} else {
 		md.WriteString(fmt.Sprintf("%s\n", entry.Content))
 	}
 	
-	if entry.URL != "" {
-		if entry.URLTitle != "" {
-			md.WriteString(fmt.Sprintf("\n[%s](%s)\n", entry.URLTitle, entry.URL))
-		} else {
-			md.WriteString(fmt.Sprintf("\n%s\n", entry.URL))
+	if len(entry.Links) > 0 {
+		md.WriteString("\n")
+		for _, link := range entry.Links {
+			if link.Title != "" {
+				md.WriteString(fmt.Sprintf("[%s](%s)\n", link.Title, link.URL))
+			} else {
+				md.WriteString(fmt.Sprintf("%s\n", link.URL))
+			}
 		}
 	}
 	
+	if attributes := formatAttributes(entry); attributes != "" {
+		md.WriteString(fmt.Sprintf("\n*%s*\n", attributes))
+	}
+	
 	if len(entry.Tags) > 0 {
 		md.WriteString(fmt.Sprintf("\n*Tags: %s*\n", strings.Join(entry.Tags, ", ")))
 	}
@@ -227,35 +515,205 @@ tagMatch := false
 		}
 	}
 	
-	urlMatch := strings.Contains(strings.ToLower(entry.URL), query) || 
-	           strings.Contains(strings.ToLower(entry.URLTitle), query)
+	urlMatch := false
+	for _, link := range entry.Links {
+		if strings.Contains(strings.ToLower(link.URL), query) ||
+			strings.Contains(strings.ToLower(link.Title), query) {
+			urlMatch = true
+			break
+		}
+	}
 	
 	return contentMatch || tagMatch || urlMatch
 }
 
-func (s *Storage) SaveEntryForTomorrow(entry *models.Entry) error {
-	tomorrow := time.Now().Add(24 * time.Hour)
-	date := tomorrow.Format(s.config.DateFormat)
-	dayFile, err := s.loadDayFile(date)
+// UpdateEntry applies update to the stored entry with id under the write
+// lock, so changes made from background work never overwrite newer edits
+// with a stale copy
+func (s *Storage) UpdateEntry(id string, update func(entry *models.Entry)) error {
+	unlock, err := s.lockDataDir()
 	if err != nil {
-		dayFile = &models.DayFile{
-			Date:    tomorrow,
-			Entries: []models.Entry{},
+		return err
+	}
+	defer unlock()
+
+	path, dayFile, idx, err := s.locateEntry(s.config.DataDir, id)
+	if err != nil {
+		return err
+	}
+
+	update(&dayFile.Entries[idx])
+	return s.saveDayFileToPath(path, dayFile)
+}
+
+// lockDataDir takes the in-process write mutex and the advisory lock file in
+// the data directory, returning a func that releases both
+func (s *Storage) lockDataDir() (func(), error) {
+	s.writeMu.Lock()
+
+	unlockFile, err := LockDataDir(s.config.DataDir)
+	if err != nil {
+		s.writeMu.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		unlockFile()
+		s.writeMu.Unlock()
+	}, nil
+}
+
+// LockDataDir blocks until it holds the advisory lock file in dir, which
+// every stak process takes before writing there, returning a func that
+// releases it. The lock is not reentrant.
+func LockDataDir(dir string) (func(), error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockFile(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock data directory: %w", err)
+	}
+	return unlock, nil
+}
+
+// DeleteEntry moves an entry from its day file into the trash
+func (s *Storage) DeleteEntry(id string) error {
+	return s.moveEntry(id, s.config.DataDir, s.trashDir(), "deleted_at")
+}
+
+// ArchiveEntry moves an entry from its day file into the archive
+func (s *Storage) ArchiveEntry(id string) error {
+	return s.moveEntry(id, s.config.DataDir, s.archiveDir(), "archived_at")
+}
+
+// RestoreEntry moves a deleted or archived entry back into its day file
+func (s *Storage) RestoreEntry(id string) error {
+	err := s.moveEntry(id, s.trashDir(), s.config.DataDir, "")
+	if errors.Is(err, ports.ErrEntryNotFound) {
+		err = s.moveEntry(id, s.archiveDir(), s.config.DataDir, "")
+	}
+	return err
+}
+
+func (s *Storage) LoadTrashedEntries() ([]models.Entry, error) {
+	return s.loadEntriesIn(s.trashDir())
+}
+
+func (s *Storage) LoadArchivedEntries() ([]models.Entry, error) {
+	return s.loadEntriesIn(s.archiveDir())
+}
+
+func (s *Storage) trashDir() string {
+	return filepath.Join(s.config.DataDir, trashDirName)
+}
+
+func (s *Storage) archiveDir() string {
+	return filepath.Join(s.config.DataDir, archiveDirName)
+}
+
+func (s *Storage) loadEntriesIn(dir string) ([]models.Entry, error) {
+	entries := []models.Entry{}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		dayFile, err := s.readDayFile(file)
+		if err != nil {
+			continue
 		}
+		entries = append(entries, dayFile.Entries...)
 	}
 
-	found := false
-	for i, existing := range dayFile.Entries {
-		if existing.ID == entry.ID {
-			dayFile.Entries[i] = *entry
-			found = true
-			break
+	return entries, nil
+}
+
+// moveEntry moves the entry with id from the day file in fromDir to the day
+// file with the same date in toDir. The destination is written first so a
+// failure part way through leaves a duplicate rather than losing the entry.
+// stampKey names the metadata timestamp recording the move; an empty key
+// clears the deleted/archived stamps instead.
+func (s *Storage) moveEntry(id, fromDir, toDir, stampKey string) error {
+	unlock, err := s.lockDataDir()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sourcePath, source, idx, err := s.locateEntry(fromDir, id)
+	if err != nil {
+		return err
+	}
+
+	entry := source.Entries[idx]
+	if entry.Metadata == nil {
+		entry.Metadata = make(map[string]string)
+	}
+	if stampKey != "" {
+		entry.Metadata[stampKey] = time.Now().Format(time.RFC3339)
+	} else {
+		delete(entry.Metadata, "deleted_at")
+		delete(entry.Metadata, "archived_at")
+	}
+
+	if err := os.MkdirAll(toDir, 0755); err != nil {
+		return err
+	}
+
+	destPath := filepath.Join(toDir, filepath.Base(sourcePath))
+	dest, err := s.loadDayFileForWrite(destPath, source.Date)
+	if err != nil {
+		return err
+	}
+	dest.Entries = append(dest.Entries, entry)
+	if err := s.saveDayFileToPath(destPath, dest); err != nil {
+		return err
+	}
+
+	source.Entries = append(source.Entries[:idx], source.Entries[idx+1:]...)
+	if len(source.Entries) == 0 {
+		return os.Remove(sourcePath)
+	}
+	return s.saveDayFileToPath(sourcePath, source)
+}
+
+// locateEntry finds the day file in dir that holds the entry with id. IDs
+// start with their creation timestamp, so that day's file is tried first
+// before falling back to scanning every day file in dir.
+func (s *Storage) locateEntry(dir, id string) (string, *models.DayFile, int, error) {
+	var candidates []string
+	if len(id) >= 8 {
+		if day, err := time.ParseInLocation("20060102", id[:8], time.Local); err == nil {
+			candidates = append(candidates, filepath.Join(dir, day.Format(s.config.DateFormat)+".md"))
 		}
 	}
 
-	if !found {
-		dayFile.Entries = append(dayFile.Entries, *entry)
+	files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return "", nil, -1, err
 	}
+	candidates = append(candidates, files...)
 
-	return s.saveDayFile(date, dayFile)
+	for _, path := range candidates {
+		dayFile, err := s.loadDayFileFromPath(path)
+		if dayFile == nil {
+			continue
+		}
+		for i, entry := range dayFile.Entries {
+			if entry.ID == id {
+				// The entry's file is about to be rewritten, so a body
+				// conflict has to be resolved first
+				if err != nil {
+					return "", nil, -1, err
+				}
+				return path, dayFile, i, nil
+			}
+		}
+	}
+
+	return "", nil, -1, fmt.Errorf("%w: %s", ports.ErrEntryNotFound, id)
 }
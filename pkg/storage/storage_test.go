@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"stak/internal/config"
+	"stak/internal/models"
+	"stak/internal/ports"
+)
+
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	cfg := config.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	s := New(cfg)
+	if err := s.Initialize(); err != nil {
+		t.Fatalf("failed to initialize storage: %v", err)
+	}
+	return s
+}
+
+func saveEntryOn(t *testing.T, s *Storage, content string, date time.Time) {
+	t.Helper()
+
+	entry := models.NewEntry(content)
+	entry.ID = content
+	entry.Type = models.TypeNote
+	entry.CreatedAt = date
+	entry.UpdatedAt = date
+	if err := s.SaveEntry(entry); err != nil {
+		t.Fatalf("failed to save entry %q: %v", content, err)
+	}
+}
+
+func TestLoadEntriesBetween(t *testing.T) {
+	s := newTestStorage(t)
+
+	base := time.Date(2025, 9, 10, 12, 0, 0, 0, time.Local)
+	saveEntryOn(t, s, "before", base.AddDate(0, 0, -5))
+	saveEntryOn(t, s, "start", base)
+	saveEntryOn(t, s, "middle", base.AddDate(0, 0, 2))
+	saveEntryOn(t, s, "end", base.AddDate(0, 0, 4))
+	saveEntryOn(t, s, "after", base.AddDate(0, 0, 5))
+	saveEntryOn(t, s, "years later", base.AddDate(3, 0, 0))
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      time.Time
+		expected []string
+	}{
+		{
+			name:     "Range includes both end days",
+			start:    base.Add(-11 * time.Hour),
+			end:      base.AddDate(0, 0, 4).Add(11 * time.Hour),
+			expected: []string{"start", "middle", "end"},
+		},
+		{
+			name:     "Single day range",
+			start:    base.AddDate(0, 0, 2),
+			end:      base.AddDate(0, 0, 2),
+			expected: []string{"middle"},
+		},
+		{
+			name:     "Range without day files",
+			start:    base.AddDate(0, 0, 10),
+			end:      base.AddDate(0, 0, 20),
+			expected: []string{},
+		},
+		{
+			name:     "Zero end leaves the range open",
+			start:    base.AddDate(0, 0, 5),
+			expected: []string{"after", "years later"},
+		},
+		{
+			name:     "Inverted range",
+			start:    base.AddDate(0, 0, 4),
+			end:      base,
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := s.LoadEntriesBetween(tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(entries) != len(tt.expected) {
+				t.Fatalf("expected %d entries, got %d: %v", len(tt.expected), len(entries), entries)
+			}
+			for i, id := range tt.expected {
+				if entries[i].ID != id {
+					t.Errorf("expected entry %d to be %q, got %q", i, id, entries[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadEntriesForDate(t *testing.T) {
+	s := newTestStorage(t)
+
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+	saveEntryOn(t, s, "first", day)
+	saveEntryOn(t, s, "second", day.Add(2*time.Hour))
+	saveEntryOn(t, s, "next day", day.AddDate(0, 0, 1))
+
+	entries, err := s.LoadEntriesForDate(day)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	entries, err = s.LoadEntriesForDate(day.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("unexpected error for missing day file: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no entries for a day without a file, got %d", len(entries))
+	}
+}
+
+func TestDeleteArchiveRestore(t *testing.T) {
+	s := newTestStorage(t)
+
+	day := time.Date(2025, 9, 10, 9, 30, 0, 0, time.Local)
+	saveEntryOn(t, s, "keep", day)
+	saveEntryOn(t, s, "trash me", day.Add(time.Minute))
+	saveEntryOn(t, s, "archive me", day.Add(2*time.Minute))
+
+	if err := s.DeleteEntry("trash me"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := s.ArchiveEntry("archive me"); err != nil {
+		t.Fatalf("archive failed: %v", err)
+	}
+
+	entries, _ := s.LoadEntriesForDate(day)
+	if len(entries) != 1 || entries[0].ID != "keep" {
+		t.Fatalf("expected only the kept entry in the day file, got %v", entries)
+	}
+
+	trashed, _ := s.LoadTrashedEntries()
+	if len(trashed) != 1 || trashed[0].Metadata["deleted_at"] == "" {
+		t.Fatalf("expected one stamped entry in the trash, got %v", trashed)
+	}
+	archived, _ := s.LoadArchivedEntries()
+	if len(archived) != 1 || archived[0].Metadata["archived_at"] == "" {
+		t.Fatalf("expected one stamped entry in the archive, got %v", archived)
+	}
+
+	all, _ := s.LoadAllEntries()
+	if len(all) != 1 {
+		t.Errorf("expected trash and archive to be excluded from all entries, got %d", len(all))
+	}
+
+	for _, id := range []string{"trash me", "archive me"} {
+		if err := s.RestoreEntry(id); err != nil {
+			t.Fatalf("restore of %q failed: %v", id, err)
+		}
+	}
+
+	entries, _ = s.LoadEntriesForDate(day)
+	if len(entries) != 3 {
+		t.Fatalf("expected all entries back in the day file, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Metadata["deleted_at"] != "" || entry.Metadata["archived_at"] != "" {
+			t.Errorf("expected removal stamps to be cleared on %q", entry.ID)
+		}
+	}
+
+	if err := s.DeleteEntry("missing"); !errors.Is(err, ports.ErrEntryNotFound) {
+		t.Errorf("expected ErrEntryNotFound for unknown id, got %v", err)
+	}
+}
+
+func TestConcurrentSaveEntry(t *testing.T) {
+	s := newTestStorage(t)
+	// A second Storage on the same directory stands in for another stak
+	// process; it shares the lock file but not the in-process mutex
+	other := New(s.config)
+
+	day := time.Date(2025, 9, 10, 9, 0, 0, 0, time.Local)
+	const writers = 6
+	const perWriter = 15
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for w := 0; w < writers; w++ {
+		store := s
+		if w%2 == 1 {
+			store = other
+		}
+		wg.Add(1)
+		go func(w int, store *Storage) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				entry := models.NewEntry(fmt.Sprintf("writer %d entry %d", w, i))
+				entry.ID = fmt.Sprintf("w%d-%d", w, i)
+				entry.Type = models.TypeNote
+				entry.CreatedAt = day.Add(time.Duration(i) * time.Second)
+				if err := store.SaveEntry(entry); err != nil {
+					errs <- err
+				}
+			}
+		}(w, store)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("concurrent save failed: %v", err)
+	}
+
+	entries, err := s.LoadEntriesForDate(day)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != writers*perWriter {
+		t.Errorf("expected %d entries after concurrent saves, got %d", writers*perWriter, len(entries))
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(s.config.DataDir, ".*.tmp"))
+	if len(leftovers) > 0 {
+		t.Errorf("expected no temp files to be left behind, got %v", leftovers)
+	}
+}
+
+func TestUpdateEntryDuringSaves(t *testing.T) {
+	s := newTestStorage(t)
+
+	day := time.Date(2025, 9, 10, 9, 0, 0, 0, time.Local)
+	saveEntryOn(t, s, "link", day)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			entry := models.NewEntry(fmt.Sprintf("note %d", i))
+			entry.ID = entry.Content
+			entry.Type = models.TypeNote
+			entry.CreatedAt = day.Add(time.Minute)
+			entry.UpdatedAt = entry.CreatedAt
+			if err := s.SaveEntry(entry); err != nil {
+				t.Errorf("save failed: %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			err := s.UpdateEntry("link", func(entry *models.Entry) {
+				entry.Links = []models.Link{{URL: "https://go.dev", Title: fmt.Sprintf("title %d", i)}}
+			})
+			if err != nil {
+				t.Errorf("update failed: %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	entries, _ := s.LoadEntriesForDate(day)
+	if len(entries) != 51 {
+		t.Fatalf("expected 51 entries, got %d", len(entries))
+	}
+	if links := entries[0].Links; len(links) != 1 || links[0].Title != "title 49" {
+		t.Errorf("expected the last update to stick, got %+v", links)
+	}
+}
+
+func TestWriteFileAtomicReplacesContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "2025-09-10.md")
+
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content), 0644); err != nil {
+			t.Fatalf("atomic write failed: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Fatalf("expected %q, got %q (err %v)", content, data, err)
+		}
+	}
+
+	files, _ := os.ReadDir(filepath.Dir(path))
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".tmp") {
+			t.Errorf("unexpected temp file %s", file.Name())
+		}
+	}
+}
+
+func TestSearchInlineTokens(t *testing.T) {
+	s := newTestStorage(t)
+	day := time.Date(2025, 9, 10, 12, 0, 0, 0, time.Local)
+
+	for i, content := range []string{
+		"review deploy script with @sam +infra",
+		"deploy notes #ops",
+		"lunch with @sam",
+	} {
+		entry := models.NewEntry(content)
+		entry.ID = content
+		entry.Type = models.TypeNote
+		entry.CreatedAt = day.Add(time.Duration(i) * time.Minute)
+		entry.ApplyInlineTokens()
+		if err := s.SaveEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"@sam", []string{"review deploy script with @sam +infra", "lunch with @sam"}},
+		{"@sam deploy", []string{"review deploy script with @sam +infra"}},
+		{"+infra @sam", []string{"review deploy script with @sam +infra"}},
+		{"#ops", []string{"deploy notes #ops"}},
+		{"#infra", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := s.SearchEntries(tt.query, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, entry := range results {
+				got = append(got, entry.Content)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package ui
 
 import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"slices"
 	"stak/internal/models"
 	"time"
 
@@ -23,6 +27,22 @@ type calendarEntriesLoadedMsg struct {
 
 type entryAddedMsg struct{}
 
+// linkRetryInterval is how often link fetches that failed are tried again
+const linkRetryInterval = 5 * time.Minute
+
+// retryLinksMsg asks for a round of link retries
+type retryLinksMsg struct{}
+
+// linksRetriedMsg reports a finished round of link retries
+type linksRetriedMsg struct {
+	retried int
+}
+
+// statusErrorMsg reports an error from an async command in the status bar
+type statusErrorMsg struct {
+	text string
+}
+
 func (m Model) loadTodayEntries() tea.Cmd {
 	return func() tea.Msg {
 		entries, err := m.entryService.LoadTodayEntries()
@@ -33,28 +53,88 @@ func (m Model) loadTodayEntries() tea.Cmd {
 	}
 }
 
+// trainCategorizer brings a learning categorizer up to date with the
+// stored entries, off the UI goroutine since it reads every day file
+func (m Model) trainCategorizer() tea.Cmd {
+	return func() tea.Msg {
+		if err := m.entryService.TrainCategorizer(); err != nil {
+			return statusErrorMsg{text: fmt.Sprintf("Training the categorizer failed: %v", err)}
+		}
+		return nil
+	}
+}
+
+// retryLinks fetches again the links whose metadata could not be fetched
+// earlier, so the entries can be reloaded once they are in
+func (m Model) retryLinks() tea.Cmd {
+	return func() tea.Msg {
+		retried := m.entryService.RetryLinks()
+		return linksRetriedMsg{retried: retried}
+	}
+}
+
 func (m Model) searchEntries(query string, linksOnly bool) tea.Cmd {
 	return func() tea.Msg {
 		entries, err := m.entryService.SearchEntries(query, linksOnly)
 		if err != nil {
-			return entriesLoadedMsg{entries: []models.Entry{}}
+			return filteredEntriesLoadedMsg{entries: []models.Entry{}, mode: searchMode}
 		}
 
-		return entriesLoadedMsg{entries: entries}
+		return filteredEntriesLoadedMsg{entries: entries, mode: searchMode}
+	}
+}
+
+// openURL opens a link with the platform's default handler
+func openURL(url string) tea.Cmd {
+	return func() tea.Msg {
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", url)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+		default:
+			cmd = exec.Command("xdg-open", url)
+		}
+
+		if err := cmd.Start(); err != nil {
+			return statusErrorMsg{text: fmt.Sprintf("Could not open link: %v", err)}
+		}
+		go cmd.Wait() // Reap the process without blocking the UI
+		return nil
 	}
 }
 
 func (m Model) loadFilteredEntries() tea.Cmd {
 	currentMode := m.currentMode // Capture current mode
+	if currentMode == searchMode {
+		// Re-run the active search so results reflect any changes
+		return m.searchEntries(m.searchQuery, m.searchLinksOnly)
+	}
 	return func() tea.Msg {
 		var entries []models.Entry
 		var err error
 
 		switch currentMode {
 		case todoMode:
-			entries, err = m.entryService.LoadFilteredEntries(models.TypeTodo)
+			switch m.todoScope {
+			case weekTodos:
+				entries, err = m.loadWeekTodos()
+			case monthTodos:
+				entries, err = m.loadMonthTodos()
+			default:
+				entries, err = m.entryService.LoadFilteredEntries(models.TypeTodo)
+			}
+			if m.todoStatus != "" {
+				entries = slices.DeleteFunc(entries, func(entry models.Entry) bool {
+					return entry.TodoStatus != m.todoStatus
+				})
+			}
+			sortTodos(entries, time.Now())
 		case stakMode:
 			entries, err = m.entryService.LoadTodayEntries()
+		case trashMode:
+			entries, err = m.entryService.LoadRemovedEntries()
 		default:
 			entries, err = m.entryService.LoadTodayEntries()
 		}
@@ -72,10 +152,10 @@ func (m Model) loadCalendarEntries() tea.Cmd {
 	return func() tea.Msg {
 		// Load entries for the current month
 		startOfMonth := time.Date(selectedDate.Year(), selectedDate.Month(), 1, 0, 0, 0, 0, selectedDate.Location())
-		_ = startOfMonth // TODO: Use for date range queries when implemented
+		endOfMonth := startOfMonth.AddDate(0, 1, -1)
 
-		// Load all entries to populate the calendar
-		entries, err := m.entryService.LoadAllEntries()
+		// Only the day files of the visible month are needed to populate the calendar
+		entries, err := m.entryService.LoadEntriesBetween(startOfMonth, endOfMonth)
 		if err != nil {
 			return calendarEntriesLoadedMsg{
 				calendarEntries: make(map[string][]models.Entry),
@@ -100,22 +180,11 @@ func (m Model) loadCalendarEntries() tea.Cmd {
 // Load entries for a specific date
 func (m Model) loadEntriesForDate(date time.Time) tea.Cmd {
 	return func() tea.Msg {
-		// Load all entries and filter by date
-		allEntries, err := m.entryService.LoadAllEntries()
+		dayEntries, err := m.entryService.LoadEntriesForDate(date)
 		if err != nil {
 			return entriesLoadedMsg{entries: []models.Entry{}}
 		}
 
-		// Filter entries for the specific date
-		dateKey := date.Format("2006-01-02")
-		var dayEntries []models.Entry
-		for _, entry := range allEntries {
-			entryDateKey := entry.CreatedAt.Format("2006-01-02")
-			if entryDateKey == dateKey {
-				dayEntries = append(dayEntries, entry)
-			}
-		}
-
 		return entriesLoadedMsg{entries: dayEntries}
 	}
 }
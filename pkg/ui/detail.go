@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"stak/internal/models"
+)
+
+var detailLabelStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#888888")).
+	Width(10)
+
+// openDetail shows the selected entry with the reasons for its type
+func (m Model) openDetail() (tea.Model, tea.Cmd) {
+	entry := m.entries[m.selectedIdx]
+	m.detail = &entry
+	return m, nil
+}
+
+// handleDetailKey answers a key pressed while the detail view is open. A
+// digit accepts that runner-up type; anything else closes the view.
+func (m Model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	entry := *m.detail
+	m.detail = nil
+
+	key := msg.String()
+	if len(key) != 1 || key[0] < '1' || key[0] > '9' {
+		return m, nil
+	}
+
+	c, ok := entry.Categorization()
+	choice := int(key[0] - '1')
+	if !ok || choice >= len(c.RunnersUp) {
+		m.detail = &entry
+		return m, nil
+	}
+
+	entryType := c.RunnersUp[choice].Type
+	if _, err := m.entryService.SetEntryType(entry.ID, entryType); err != nil {
+		m.errorMessage = fmt.Sprintf("Change type failed: %v", err)
+		m.errorTime = time.Now()
+		return m, nil
+	}
+
+	m.errorMessage = fmt.Sprintf("Entry is now a %s", entryType)
+	m.errorTime = time.Now()
+	return m, m.reloadEntries()
+}
+
+// renderDetailClean lists an entry's fields and why it got its type
+func (m Model) renderDetailClean() string {
+	entry := *m.detail
+	row := func(label, value string) string {
+		return detailLabelStyle.Render(label) + value
+	}
+
+	lines := []string{entry.Content, ""}
+
+	c, explained := entry.Categorization()
+	typeLine := string(entry.Type)
+	if explained {
+		typeLine += fmt.Sprintf(" (%.0f%% sure)", 100*c.Confidence)
+	}
+	lines = append(lines, row("Type", typeLine))
+	if len(entry.Tags) > 0 {
+		lines = append(lines, row("Tags", strings.Join(entry.Tags, ", ")))
+	}
+	lines = append(lines, row("Created", entry.CreatedAt.Format("2006-01-02 15:04")))
+	if entry.Priority != "" {
+		lines = append(lines, row("Priority", string(entry.Priority)))
+	}
+	if due := entry.FormatDue(); due != "" {
+		lines = append(lines, row("Due", due))
+	}
+	for _, link := range entry.Links {
+		lines = append(lines, row("Link", link.URL))
+		if link.Title != "" {
+			lines = append(lines, row("Title", link.Title))
+		}
+		if check := link.Check; check != nil {
+			lines = append(lines, row("Checked", describeCheck(*check)))
+		}
+		meta := link.Metadata
+		if meta == nil {
+			continue
+		}
+		if meta.SiteName != "" {
+			lines = append(lines, row("Site", meta.SiteName))
+		}
+		if meta.Description != "" {
+			lines = append(lines, row("About", meta.Description))
+		}
+		if meta.CanonicalURL != "" && meta.CanonicalURL != link.URL {
+			lines = append(lines, row("Canonical", meta.CanonicalURL))
+		}
+		if meta.ContentType != "" && meta.ContentType != "text/html" {
+			lines = append(lines, row("Content", meta.ContentType))
+		}
+	}
+	for i, entity := range entry.Entities {
+		label := ""
+		if i == 0 {
+			label = "Entities"
+		}
+		value := fmt.Sprintf("%s %s", entity.Kind, entity.Value)
+		if entity.URL != "" {
+			value = fmt.Sprintf("%s %s → %s", entity.Kind, hyperlink(entity.URL, entityLinkStyle.Render(entity.Value)), entity.URL)
+		}
+		lines = append(lines, row(label, value))
+	}
+
+	if !explained {
+		lines = append(lines, "", "No categorization recorded for this entry.", "", "Esc to close")
+		return strings.Join(lines, "\n")
+	}
+
+	lines = append(lines, "")
+	for i, reason := range c.Reasons {
+		label := ""
+		if i == 0 {
+			label = "Why"
+		}
+		lines = append(lines, row(label, reason))
+	}
+
+	footer := "Esc to close"
+	for i, runnerUp := range c.RunnersUp {
+		if i == 9 {
+			break
+		}
+		label := ""
+		if i == 0 {
+			label = "Instead"
+			footer = "1-9 to use that type instead, Esc to close"
+		}
+		lines = append(lines, row(label, fmt.Sprintf("%d  %s (%.0f%%)", i+1, runnerUp.Type, 100*runnerUp.Confidence)))
+	}
+
+	lines = append(lines, "", footer)
+	return strings.Join(lines, "\n")
+}
+
+// describeCheck says how a link fared when it was last checked, as in
+// "404 (dead) on 2025-09-10"
+func describeCheck(check models.LinkCheck) string {
+	result := fmt.Sprint(check.Status)
+	switch {
+	case check.Error != "":
+		result = check.Error + " (dead)"
+	case check.Dead():
+		result += " (dead)"
+	case check.Moved():
+		result += " → " + check.RedirectURL
+	}
+	return result + " on " + check.CheckedAt.Format("2006-01-02")
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"stak/internal/application"
+)
+
+// pendingDuplicate is a capture repeating a link saved before, waiting to
+// be merged into that entry, saved anyway or dropped
+type pendingDuplicate struct {
+	content  string
+	previous application.LinkDuplicate
+}
+
+// addEntry saves content as a new entry, first asking what to do when it
+// repeats a link saved before
+func (m Model) addEntry(content string) (tea.Model, tea.Cmd) {
+	duplicates, err := m.entryService.FindDuplicateLinks(content)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Save failed: %v", err)
+		m.errorTime = time.Now()
+		return m, nil
+	}
+	if len(duplicates) > 0 {
+		m.duplicate = &pendingDuplicate{content: content, previous: duplicates[0]}
+		return m, nil
+	}
+	return m.saveEntry(content)
+}
+
+// handleDuplicateKey merges the pending capture into the earlier entry on
+// m, saves it as a new entry on s or enter, and leaves it in the input to
+// change on any other key
+func (m Model) handleDuplicateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	pending := m.duplicate
+	m.duplicate = nil
+
+	switch msg.String() {
+	case "m", "M":
+		if _, err := m.entryService.MergeEntry(pending.previous.Entry.ID, pending.content); err != nil {
+			m.errorMessage = fmt.Sprintf("Merge failed: %v", err)
+			m.errorTime = time.Now()
+			return m, nil
+		}
+		m.textInput.SetValue("")
+		m.errorMessage = "Merged into the entry saved " + pending.previous.Entry.CreatedAt.Format("Jan 2")
+		m.errorTime = time.Now()
+		return m, m.reloadEntries()
+	case "s", "S", "enter":
+		return m.saveEntry(pending.content)
+	}
+	return m, nil
+}
+
+// duplicatePrompt says where and when the pending capture's link was saved
+func (m Model) duplicatePrompt() string {
+	previous := m.duplicate.previous.Entry
+	return fmt.Sprintf("Saved %s: \"%s\" • m merge, s save anyway, esc cancel",
+		previous.CreatedAt.Format("Mon Jan 2 15:04"), firstLine(previous.Content))
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"stak/internal/application"
+)
+
+// linkReport is what /linkcheck found, shown until the next key
+type linkReport struct {
+	checked int
+	broken  []application.LinkCheckResult // dead and moved links
+}
+
+// linksCheckedMsg carries the outcome of /linkcheck
+type linksCheckedMsg struct {
+	results []application.LinkCheckResult
+	update  bool
+	err     error
+}
+
+// checkLinks checks every link entry's links in the background, changing
+// moved links to where they redirect when update is set
+func (m Model) checkLinks(update bool) tea.Cmd {
+	return func() tea.Msg {
+		results, err := m.entryService.CheckLinks(0, update)
+		return linksCheckedMsg{results: results, update: update, err: err}
+	}
+}
+
+// linksChecked shows the dead and moved links a check found
+func (m *Model) linksChecked(msg linksCheckedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.errorMessage = fmt.Sprintf("Link check failed: %v", msg.err)
+		m.errorTime = time.Now()
+		return nil
+	}
+
+	report := &linkReport{checked: len(msg.results)}
+	for _, r := range msg.results {
+		if r.Check.Dead() || r.Check.Moved() {
+			report.broken = append(report.broken, r)
+		}
+	}
+	m.linkReport = report
+
+	if msg.update {
+		return m.reloadEntries()
+	}
+	return nil
+}
+
+// renderLinkReportClean lists the dead and moved links, one per line
+func (m Model) renderLinkReportClean() string {
+	report := m.linkReport
+	lines := []string{
+		fmt.Sprintf("Checked %d links, %d dead or moved:", report.checked, len(report.broken)),
+		"",
+	}
+	for _, r := range report.broken {
+		line := fmt.Sprintf("dead   %s  %s", checkStatus(r), r.URL)
+		if r.Check.Moved() {
+			line = fmt.Sprintf("moved  %s  %s → %s", checkStatus(r), r.URL, r.Check.RedirectURL)
+			if r.Updated {
+				line += " (updated)"
+			}
+		}
+		lines = append(lines, line, "       "+firstLine(r.Entry.Content))
+	}
+	lines = append(lines, "", "/linkcheck update changes moved links to where they redirect • any key to close")
+	return strings.Join(lines, "\n")
+}
+
+// checkStatus is the HTTP status a check ended on, or why it failed
+func checkStatus(r application.LinkCheckResult) string {
+	if r.Check.Error != "" {
+		return r.Check.Error
+	}
+	return fmt.Sprint(r.Check.Status)
+}
@@ -9,7 +9,10 @@ import (
 	"stak/internal/config"
 	"stak/internal/models"
 	"stak/pkg/categorizer"
+	"stak/pkg/dateparse"
+	"stak/pkg/entities"
 	"stak/pkg/extractor"
+	"stak/pkg/linkcache"
 	"stak/pkg/search"
 	"stak/pkg/storage"
 
@@ -25,6 +28,17 @@ const (
 	stakMode mode = iota // Renamed from scratchpadMode
 	todoMode
 	calendarMode
+	searchMode
+	trashMode
+)
+
+// todoScope limits which todos TODO mode shows
+type todoScope int
+
+const (
+	allTodos todoScope = iota
+	weekTodos
+	monthTodos
 )
 
 type calendarPane int
@@ -43,6 +57,12 @@ type keyMap struct {
 	ShiftTab key.Binding
 	Enter    key.Binding
 	Edit     key.Binding
+	Open     key.Binding
+	Delete   key.Binding
+	Archive  key.Binding
+	Restore  key.Binding
+	Info     key.Binding
+	Cancel   key.Binding
 	Quit     key.Binding
 	Help     key.Binding
 }
@@ -54,7 +74,8 @@ func (k keyMap) ShortHelp() []key.Binding {
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Tab, k.ShiftTab},
-		{k.Enter, k.Edit, k.Help, k.Quit},
+		{k.Enter, k.Edit, k.Open, k.Help, k.Quit},
+		{k.Delete, k.Archive, k.Restore, k.Info, k.Cancel},
 	}
 }
 
@@ -81,7 +102,31 @@ var keys = keyMap{
 	),
 	Edit: key.NewBinding(
 		key.WithKeys("e"),
-		key.WithHelp("e", "edit todo"),
+		key.WithHelp("e", "edit entry"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open link"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete"),
+	),
+	Archive: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "archive"),
+	),
+	Restore: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "restore"),
+	),
+	Info: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "details"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "cancel/reopen todo"),
 	),
 	Help: key.NewBinding(
 		key.WithKeys("?"),
@@ -109,15 +154,33 @@ type Model struct {
 	selectedIdx   int
 	searchQuery   string
 	showHelp      bool
+	todoScope     todoScope
+	todoStatus    models.TodoStatus // TODO mode shows only this status, or all when empty
+	// Search mode fields
+	searchLinksOnly     bool
+	previousMode        mode         // mode to return to when leaving search
+	previousSelectedIdx int          // selection to restore when leaving search
+	previousPane        calendarPane // calendar pane to restore when leaving search
 	// Calendar mode fields
 	selectedDate    time.Time
 	calendarEntries map[string][]models.Entry // date -> entries
 	activePane      calendarPane              // for tab navigation in calendar mode
 	help            help.Model
 	keys            keyMap
-	// TODO editing state
-	editingTodoIdx  int    // -1 when not editing
+	// Entry editing state
+	editingIdx      int    // -1 when not editing
 	originalContent string // backup for cancel
+	// Delete confirmation state
+	confirmDeleteID      string // ID awaiting y/n, empty when not confirming
+	confirmDeleteContent string // shown in the confirmation prompt
+	// Capture repeating a saved link, awaiting m/s/esc
+	duplicate *pendingDuplicate
+	// Entry detail view, nil when closed
+	detail *models.Entry
+	// Changes /recategorize would make, awaiting y/n
+	recategorizePreview []application.Recategorization
+	// Dead and moved links /linkcheck found, nil when closed
+	linkReport *linkReport
 	// Error handling
 	errorMessage string    // Error message to show in status bar
 	errorTime    time.Time // When error was shown
@@ -131,12 +194,12 @@ func NewModel() *Model {
 func NewModelWithConfig(cfg *config.Config) *Model {
 	// Create dependencies
 	storage := storage.New(cfg)
-	categoriser := categorizer.New()
+	categoriser := categorizer.ForConfig(cfg)
 	searcher := search.NewFuzzySearcher()
-	extractor := extractor.NewLinkExtractor()
+	extractor := extractor.NewLinkExtractorWithConfig(cfg.Links)
 
 	// Create application service
-	entryService := application.NewEntryService(storage, categoriser, extractor, searcher)
+	entryService := application.NewEntryService(storage, categoriser, entities.NewWithConfig(cfg.Entities), extractor, linkcache.New(cfg.DataDir, cfg.Links.CacheTTL), searcher, dateparse.New())
 
 	ti := textinput.New()
 	ti.Placeholder = "Enter your thoughts, links, todos..."
@@ -160,14 +223,31 @@ func NewModelWithConfig(cfg *config.Config) *Model {
 		currentMode:  stakMode,
 		commands: []string{
 			"Shift+Tab - Toggle between STAK and TODO mode",
-			"/todos - Switch to TODO mode",
+			"/todos [week|month] [pending|completed|cancelled] - Switch to TODO mode, optionally limited to recent todos or one status",
 			"/cal - Calendar view with date picker",
+			"/today - Show today's entries",
+			"/search <query> or /s <query> - Search all entries",
+			"/sl <query> - Search links only",
+			"/trash - Show deleted and archived entries, r to restore",
+			"/type <type> - Change the selected entry's type",
+			"/recategorize - Re-run categorization over stored entries, with a preview",
+			"/linkcheck [update] - Report dead and moved links, optionally following redirects",
+			"Tab to focus entries, then d to delete, a to archive, i for details, c to cancel or reopen a todo",
+			"In search: Tab to focus results, Enter to toggle/open, e to edit, o to open link, Esc to go back",
 			"/help - Show this help",
 			"/quit - Exit stak",
 		},
 		slashCommands: []string{
 			"/todos",
 			"/cal",
+			"/today",
+			"/search",
+			"/s",
+			"/sl",
+			"/trash",
+			"/type",
+			"/recategorize",
+			"/linkcheck",
 			"/help",
 			"/quit",
 		},
@@ -178,7 +258,7 @@ func NewModelWithConfig(cfg *config.Config) *Model {
 		activePane:      inputPane,
 		help:            h,
 		keys:            keys,
-		editingTodoIdx:  -1, // Not editing by default
+		editingIdx:      -1, // Not editing by default
 	}
 
 	model.updatePrompt() // Set initial prompt
@@ -189,6 +269,8 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		textinput.Blink,
 		m.loadFilteredEntries(),
+		m.retryLinks(),
+		m.trainCategorizer(),
 	)
 }
 
@@ -214,6 +296,33 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ready = true
 
 	case tea.KeyMsg:
+		// A pending delete swallows the next key as its answer
+		if m.confirmDeleteID != "" {
+			if msg.String() == "y" || msg.String() == "Y" {
+				return m.deleteConfirmed()
+			}
+			m.confirmDeleteID = ""
+			m.confirmDeleteContent = ""
+			return m, nil
+		}
+
+		// So does a capture repeating a saved link
+		if m.duplicate != nil {
+			return m.handleDuplicateKey(msg)
+		}
+
+		// An open detail view takes the next key
+		if m.detail != nil {
+			return m.handleDetailKey(msg)
+		}
+		if m.recategorizePreview != nil {
+			return m.handleRecategorizeKey(msg)
+		}
+		if m.linkReport != nil {
+			m.linkReport = nil
+			return m, nil
+		}
+
 		// Check for help key first
 		if key.Matches(msg, m.keys.Help) {
 			m.help.ShowAll = !m.help.ShowAll
@@ -229,6 +338,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.showHelp = false
 				return m, nil
 			}
+			if m.editingIdx >= 0 {
+				return m.cancelEditing()
+			}
+			if m.currentMode == searchMode {
+				return m.exitSearch()
+			}
+			if m.currentMode == trashMode {
+				m.currentMode = stakMode
+				m.selectedIdx = -1
+				m.textInput.Focus()
+				return m, m.loadFilteredEntries()
+			}
 			if m.currentMode == calendarMode {
 				m.currentMode = stakMode
 				return m, m.loadTodayEntries()
@@ -243,7 +364,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.currentMode = calendarMode
 				m.activePane = inputPane // Reset pane navigation
 				m.textInput.Focus()      // Make sure input is focused
-			case calendarMode:
+			case calendarMode, searchMode, trashMode:
 				m.currentMode = stakMode
 				m.activePane = inputPane // Reset pane navigation
 				m.textInput.Focus()      // Make sure input is focused
@@ -265,6 +386,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
+			// If editing an entry, save the changes
+			if m.editingIdx >= 0 && m.editingIdx < len(m.entries) {
+				return m.saveEditing()
+			}
+
 			// Handle enter in calendar mode
 			if m.currentMode == calendarMode {
 				switch m.activePane {
@@ -283,12 +409,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+			// Handle enter in search mode
+			if m.currentMode == searchMode {
+				// Toggle todos or open links in the results list
+				if !m.textInput.Focused() && m.selectedIdx >= 0 && m.selectedIdx < len(m.entries) {
+					if m.entries[m.selectedIdx].Type == models.TypeTodo {
+						return m.toggleTodo()
+					}
+					return m.openSelectedLink()
+				}
+			}
+
 			// Handle enter in TODO mode
 			if m.currentMode == todoMode {
-				// If editing a todo, save the changes
-				if m.editingTodoIdx >= 0 && m.editingTodoIdx < len(m.entries) {
-					return m.saveEditingTodo()
-				}
 				// If a todo is selected (not in input), toggle it
 				if !m.textInput.Focused() && m.selectedIdx >= 0 && m.selectedIdx < len(m.entries) {
 					return m.toggleTodo()
@@ -308,9 +441,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				case datePickerPane:
 					// Navigate calendar dates spatially (up = day above in grid)
-					m.selectedDate = m.getSpatialDate(m.selectedDate, "up")
-					// Update entries for new date
-					cmds = append(cmds, m.loadEntriesForDate(m.selectedDate))
+					cmds = append(cmds, m.moveSelectedDate("up"))
 				}
 			} else {
 				// Normal up arrow behavior for other modes
@@ -330,9 +461,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				case datePickerPane:
 					// Navigate calendar dates spatially (down = day below in grid)
-					m.selectedDate = m.getSpatialDate(m.selectedDate, "down")
-					// Update entries for new date
-					cmds = append(cmds, m.loadEntriesForDate(m.selectedDate))
+					cmds = append(cmds, m.moveSelectedDate("down"))
 				}
 			} else {
 				// Normal down arrow behavior for other modes
@@ -344,17 +473,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyLeft:
 			if m.currentMode == calendarMode && m.activePane == datePickerPane {
 				// Navigate calendar dates spatially (left = day to the left in grid)
-				m.selectedDate = m.getSpatialDate(m.selectedDate, "left")
-				// Update entries for new date
-				cmds = append(cmds, m.loadEntriesForDate(m.selectedDate))
+				cmds = append(cmds, m.moveSelectedDate("left"))
 			}
 
 		case tea.KeyRight:
 			if m.currentMode == calendarMode && m.activePane == datePickerPane {
 				// Navigate calendar dates spatially (right = day to the right in grid)
-				m.selectedDate = m.getSpatialDate(m.selectedDate, "right")
-				// Update entries for new date
-				cmds = append(cmds, m.loadEntriesForDate(m.selectedDate))
+				cmds = append(cmds, m.moveSelectedDate("right"))
 			}
 
 		case tea.KeyTab:
@@ -379,8 +504,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selectedIdx = -1
 				}
 				return m, nil
-			} else if m.currentMode == todoMode {
-				// In TODO mode, tab switches between input and todo list navigation
+			} else {
+				// In other modes, tab switches between input and list navigation
 				if m.textInput.Focused() {
 					m.textInput.Blur()
 					// Focus on todo list - set selectedIdx if not already set
@@ -393,42 +518,68 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return m, nil
 			}
-			// STAK mode: Tab does nothing (could add basic completion later)
 
 		default:
-			// Handle special keys in TODO mode
-			if m.currentMode == todoMode && !m.textInput.Focused() && m.selectedIdx >= 0 && m.selectedIdx < len(m.entries) {
-				switch msg.String() {
-				case "e":
-					// Enter edit mode
-					return m.startEditingTodo()
-				case "right":
+			// Entry actions work on any focused entry list
+			if m.entryListFocused() {
+				switch {
+				case m.currentMode != trashMode && key.Matches(msg, m.keys.Edit):
+					return m.startEditing()
+				case m.currentMode == todoMode && msg.String() == "right":
 					// TODO: Show context menu (edit/delete)
 					// For now, just start editing
-					return m.startEditingTodo()
+					return m.startEditing()
+				case key.Matches(msg, m.keys.Open):
+					return m.openSelectedLink()
+				case key.Matches(msg, m.keys.Info):
+					return m.openDetail()
+				case m.currentMode != trashMode && key.Matches(msg, m.keys.Cancel):
+					return m.cancelTodo()
+				case m.currentMode == trashMode && key.Matches(msg, m.keys.Restore):
+					return m.restoreSelected()
+				case m.currentMode != trashMode && key.Matches(msg, m.keys.Delete):
+					entry := m.entries[m.selectedIdx]
+					m.confirmDeleteID = entry.ID
+					m.confirmDeleteContent = entry.Content
+					return m, nil
+				case m.currentMode != trashMode && key.Matches(msg, m.keys.Archive):
+					return m.archiveSelected()
 				}
 			}
-
-			// Handle escape in edit mode
-			if m.currentMode == todoMode && m.editingTodoIdx >= 0 && msg.String() == "esc" {
-				return m.cancelEditingTodo()
-			}
 		}
 
 	case entriesLoadedMsg:
 		m.entries = msg.entries
-		if len(m.entries) > 0 && m.selectedIdx < 0 {
-			m.selectedIdx = len(m.entries) - 1
-		}
+		m.clampSelection()
 
 	case filteredEntriesLoadedMsg:
 		// Only update if the mode matches current mode (avoid race conditions)
 		if msg.mode == m.currentMode {
 			m.entries = msg.entries
-			if len(m.entries) > 0 && m.selectedIdx < 0 {
-				m.selectedIdx = len(m.entries) - 1
-			}
+			m.clampSelection()
 		}
+		m.warnConflicts()
+
+	case statusErrorMsg:
+		m.errorMessage = msg.text
+		m.errorTime = time.Now()
+
+	case retryLinksMsg:
+		cmds = append(cmds, m.retryLinks())
+
+	case linksCheckedMsg:
+		cmds = append(cmds, m.linksChecked(msg))
+
+	case recategorizedMsg:
+		cmds = append(cmds, m.recategorized(msg))
+
+	case linksRetriedMsg:
+		if msg.retried > 0 {
+			cmds = append(cmds, m.reloadEntries())
+		}
+		cmds = append(cmds, tea.Tick(linkRetryInterval, func(time.Time) tea.Msg {
+			return retryLinksMsg{}
+		}))
 
 	case entryAddedMsg:
 		if m.currentMode == calendarMode {
@@ -449,6 +600,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.entries = []models.Entry{}
 		}
+		if m.selectedIdx >= len(m.entries) {
+			m.selectedIdx = len(m.entries) - 1
+		}
 
 	case todoToggledMsg:
 		// Save the toggled todo entry
@@ -485,8 +639,9 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		return m.handleCommand(input)
 	}
 
-	if strings.HasPrefix(input, "tomorrow") {
-		return m.addTomorrowEntry(input)
+	// In search mode plain input refines the current search
+	if m.currentMode == searchMode {
+		return m.startSearch(input, m.searchLinksOnly)
 	}
 
 	return m.addEntry(input)
@@ -522,10 +677,88 @@ func (m Model) handleCommand(cmd string) (tea.Model, tea.Cmd) {
 		return m, m.loadCalendarEntries()
 
 	case "/todos":
+		scope, status := allTodos, models.TodoStatus("")
+		for _, arg := range parts[1:] {
+			switch arg {
+			case "week":
+				scope = weekTodos
+			case "month":
+				scope = monthTodos
+			case "all":
+				scope, status = allTodos, ""
+			default:
+				var ok bool
+				if status, ok = models.ParseTodoStatus(arg); !ok {
+					m.errorMessage = "Usage: /todos [week|month|all] [pending|completed|cancelled]"
+					m.errorTime = time.Now()
+					return m, nil
+				}
+			}
+		}
 		m.currentMode = todoMode
+		m.todoScope = scope
+		m.todoStatus = status
+		m.selectedIdx = -1
 		m.textInput.SetValue("")
 		return m, m.loadFilteredEntries()
 
+	case "/today":
+		m.currentMode = stakMode
+		m.activePane = inputPane
+		m.selectedIdx = -1
+		m.textInput.SetValue("")
+		m.textInput.Focus()
+		return m, m.loadFilteredEntries()
+
+	case "/trash":
+		m.currentMode = trashMode
+		m.selectedIdx = -1
+		m.textInput.SetValue("")
+		m.textInput.Blur() // Focus the list so entries can be restored
+		return m, m.loadFilteredEntries()
+
+	case "/search", "/s", "/sl":
+		query := strings.TrimSpace(strings.Join(parts[1:], " "))
+		if query == "" {
+			m.errorMessage = fmt.Sprintf("Usage: %s <query>", command)
+			m.errorTime = time.Now()
+			return m, nil
+		}
+		return m.startSearch(query, command == "/sl")
+
+	case "/recategorize":
+		m.textInput.SetValue("")
+		return m.previewRecategorize()
+
+	case "/linkcheck":
+		update := len(parts) == 2 && parts[1] == "update"
+		if len(parts) > 1 && !update {
+			m.errorMessage = "Usage: /linkcheck [update]"
+			m.errorTime = time.Now()
+			return m, nil
+		}
+		m.textInput.SetValue("")
+		m.errorMessage = "Checking links..."
+		m.errorTime = time.Now()
+		return m, m.checkLinks(update)
+
+	case "/type":
+		entryType, ok := models.EntryType(""), false
+		if len(parts) == 2 {
+			entryType, ok = models.ParseEntryType(parts[1])
+		}
+		if !ok {
+			names := make([]string, len(models.EntryTypes))
+			for i, t := range models.EntryTypes {
+				names[i] = string(t)
+			}
+			m.errorMessage = fmt.Sprintf("Usage: /type <%s>", strings.Join(names, "|"))
+			m.errorTime = time.Now()
+			return m, nil
+		}
+		m.textInput.SetValue("")
+		return m.setSelectedType(entryType)
+
 	case "/todo", "/t":
 		// Add todo without switching modes
 		args := parts[1:] // Get the text after the command
@@ -542,7 +775,8 @@ func (m Model) handleCommand(cmd string) (tea.Model, tea.Cmd) {
 				_, err = m.entryService.CreateEntry(todoText, &todoType)
 			}
 			if err != nil {
-				// TODO: Show error in status bar
+				m.errorMessage = fmt.Sprintf("Save failed: %v", err)
+				m.errorTime = time.Now()
 			}
 			m.textInput.SetValue("")
 			if m.currentMode == calendarMode {
@@ -566,7 +800,8 @@ func (m Model) handleCommand(cmd string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m Model) addEntry(content string) (tea.Model, tea.Cmd) {
+// saveEntry saves content as a new entry
+func (m Model) saveEntry(content string) (tea.Model, tea.Cmd) {
 	// Use application service for business logic
 	var forceType *models.EntryType
 	if m.currentMode == todoMode {
@@ -584,6 +819,8 @@ func (m Model) addEntry(content string) (tea.Model, tea.Cmd) {
 	}
 
 	if err != nil {
+		m.errorMessage = fmt.Sprintf("Save failed: %v", err)
+		m.errorTime = time.Now()
 		return m, nil
 	}
 
@@ -604,19 +841,224 @@ func (m Model) toggleTodo() (tea.Model, tea.Cmd) {
 	}
 
 	if entry.TodoStatus == models.TodoPending {
-		entry.TodoStatus = models.TodoCompleted
+		entry.SetTodoStatus(models.TodoCompleted, time.Now())
 	} else {
-		entry.TodoStatus = models.TodoPending
+		entry.SetTodoStatus(models.TodoPending, time.Now())
 	}
 
-	entry.UpdatedAt = time.Now()
 	if err := m.storage.SaveEntry(entry); err != nil {
+		m.errorMessage = fmt.Sprintf("Save failed: %v", err)
+		m.errorTime = time.Now()
+		return m, nil
+	}
+
+	return m, m.loadFilteredEntries()
+}
+
+// cancelTodo cancels the selected todo, or reopens it if it was cancelled
+func (m Model) cancelTodo() (tea.Model, tea.Cmd) {
+	if m.selectedIdx < 0 || m.selectedIdx >= len(m.entries) {
+		return m, nil
+	}
+
+	entry := m.entries[m.selectedIdx]
+	if entry.Type != models.TypeTodo {
 		return m, nil
 	}
 
+	status := models.TodoCancelled
+	if entry.TodoStatus == models.TodoCancelled {
+		status = models.TodoPending
+	}
+	if _, err := m.entryService.SetTodoStatus(entry.ID, status); err != nil {
+		m.errorMessage = fmt.Sprintf("Save failed: %v", err)
+		m.errorTime = time.Now()
+		return m, nil
+	}
+
+	return m, m.loadFilteredEntries()
+}
+
+// startSearch switches to search mode and runs the query, remembering the
+// mode and selection to return to when search is closed
+func (m Model) startSearch(query string, linksOnly bool) (tea.Model, tea.Cmd) {
+	if m.currentMode != searchMode {
+		m.previousMode = m.currentMode
+		m.previousSelectedIdx = m.selectedIdx
+		m.previousPane = m.activePane
+	}
+
+	m.currentMode = searchMode
+	m.searchQuery = query
+	m.searchLinksOnly = linksOnly
+	m.selectedIdx = -1
+	m.showHelp = false
+	m.textInput.SetValue("")
+	m.textInput.Blur() // Focus the results list
+
+	return m, m.searchEntries(query, linksOnly)
+}
+
+// exitSearch returns to the mode that was active before searching
+func (m Model) exitSearch() (tea.Model, tea.Cmd) {
+	m.currentMode = m.previousMode
+	m.selectedIdx = m.previousSelectedIdx
+	m.activePane = m.previousPane
+	m.searchQuery = ""
+	m.searchLinksOnly = false
+	m.textInput.SetValue("")
+
+	if m.currentMode == calendarMode {
+		if m.activePane == inputPane {
+			m.textInput.Focus()
+		} else {
+			m.textInput.Blur()
+		}
+		return m, m.loadEntriesForDate(m.selectedDate)
+	}
+
+	if m.currentMode == todoMode && m.selectedIdx >= 0 {
+		m.textInput.Blur() // Restore todo list navigation
+	} else {
+		m.textInput.Focus()
+	}
 	return m, m.loadFilteredEntries()
 }
 
+// openSelectedLink opens the first link of the selected entry in the system
+// browser
+func (m Model) openSelectedLink() (tea.Model, tea.Cmd) {
+	if m.selectedIdx < 0 || m.selectedIdx >= len(m.entries) {
+		return m, nil
+	}
+
+	// Entries without a link of their own open their first linked entity,
+	// such as a ticket or a GitHub issue
+	entry := m.entries[m.selectedIdx]
+	url := entry.EntityURL()
+	if len(entry.Links) > 0 {
+		url = entry.Links[0].URL
+	}
+	if url == "" {
+		m.errorMessage = "Entry has no link to open"
+		m.errorTime = time.Now()
+		return m, nil
+	}
+
+	return m, openURL(url)
+}
+
+// entryListFocused reports whether keys should act on the selected entry
+// rather than being typed into the input
+func (m Model) entryListFocused() bool {
+	if m.selectedIdx < 0 || m.selectedIdx >= len(m.entries) || m.editingIdx >= 0 {
+		return false
+	}
+	if m.currentMode == calendarMode {
+		return m.activePane == entriesPane
+	}
+	return !m.textInput.Focused()
+}
+
+// deleteConfirmed moves the entry awaiting confirmation to the trash
+func (m Model) deleteConfirmed() (tea.Model, tea.Cmd) {
+	id := m.confirmDeleteID
+	m.confirmDeleteID = ""
+	m.confirmDeleteContent = ""
+
+	if err := m.entryService.DeleteEntry(id); err != nil {
+		m.errorMessage = fmt.Sprintf("Delete failed: %v", err)
+		m.errorTime = time.Now()
+		return m, nil
+	}
+
+	m.errorMessage = "Entry moved to trash (/trash to restore)"
+	m.errorTime = time.Now()
+	return m, m.reloadEntries()
+}
+
+func (m Model) archiveSelected() (tea.Model, tea.Cmd) {
+	entry := m.entries[m.selectedIdx]
+	if err := m.entryService.ArchiveEntry(entry.ID); err != nil {
+		m.errorMessage = fmt.Sprintf("Archive failed: %v", err)
+		m.errorTime = time.Now()
+		return m, nil
+	}
+
+	m.errorMessage = "Entry archived (/trash to restore)"
+	m.errorTime = time.Now()
+	return m, m.reloadEntries()
+}
+
+func (m Model) restoreSelected() (tea.Model, tea.Cmd) {
+	entry := m.entries[m.selectedIdx]
+	if err := m.entryService.RestoreEntry(entry.ID); err != nil {
+		m.errorMessage = fmt.Sprintf("Restore failed: %v", err)
+		m.errorTime = time.Now()
+		return m, nil
+	}
+
+	m.errorMessage = "Entry restored"
+	m.errorTime = time.Now()
+	return m, m.reloadEntries()
+}
+
+// setSelectedType changes the selected entry's type to one the user picked
+func (m Model) setSelectedType(entryType models.EntryType) (tea.Model, tea.Cmd) {
+	if m.selectedIdx < 0 || m.selectedIdx >= len(m.entries) {
+		m.errorMessage = "Select an entry first (Tab to focus entries)"
+		m.errorTime = time.Now()
+		return m, nil
+	}
+
+	entry := m.entries[m.selectedIdx]
+	if _, err := m.entryService.SetEntryType(entry.ID, entryType); err != nil {
+		m.errorMessage = fmt.Sprintf("Change type failed: %v", err)
+		m.errorTime = time.Now()
+		return m, nil
+	}
+
+	m.errorMessage = fmt.Sprintf("Entry is now a %s", entryType)
+	m.errorTime = time.Now()
+	return m, m.reloadEntries()
+}
+
+// reloadEntries refreshes whatever the current mode is showing
+// warnConflicts shows the first day file whose hand edited body could not
+// be merged, since its edits are not shown and it is not written until
+// resolved
+func (m *Model) warnConflicts() {
+	conflicts := m.entryService.BodyConflicts()
+	if len(conflicts) == 0 {
+		return
+	}
+	text := fmt.Sprintf("%v; run stak resolve to keep the front matter", conflicts[0])
+	if len(conflicts) > 1 {
+		text += fmt.Sprintf(" (%d more files)", len(conflicts)-1)
+	}
+	m.errorMessage = text
+	m.errorTime = time.Now()
+}
+
+func (m Model) reloadEntries() tea.Cmd {
+	if m.currentMode == calendarMode {
+		// Reload the month so the calendar highlights stay in sync
+		return m.loadCalendarEntries()
+	}
+	return m.loadFilteredEntries()
+}
+
+// clampSelection keeps selectedIdx within the bounds of the loaded entries
+func (m *Model) clampSelection() {
+	if len(m.entries) == 0 {
+		m.selectedIdx = -1
+		return
+	}
+	if m.selectedIdx < 0 || m.selectedIdx >= len(m.entries) {
+		m.selectedIdx = len(m.entries) - 1
+	}
+}
+
 func (m *Model) Storage() *storage.Storage {
 	return m.storage
 }
@@ -644,121 +1086,88 @@ func (m *Model) updateSuggestions() {
 	}
 }
 
-func (m Model) addTomorrowEntry(content string) (tea.Model, tea.Cmd) {
-	if err := m.entryService.CreateTomorrowEntry(content); err != nil {
-		return m, nil
-	}
-
-	m.textInput.SetValue("")
-	return m, func() tea.Msg {
-		return entryAddedMsg{}
-	}
-}
-
 // Load todos from the past week
 func (m Model) loadWeekTodos() ([]models.Entry, error) {
 	now := time.Now()
-	weekAgo := now.AddDate(0, 0, -7)
-
-	// For now, load today's entries and filter by date
-	// TODO: Extend storage service to support date range queries
-	allEntries, err := m.storage.LoadTodayEntries()
-	if err != nil {
-		return []models.Entry{}, err
-	}
-
-	var weekEntries []models.Entry
-	for _, entry := range allEntries {
-		if entry.Type == models.TypeTodo && entry.CreatedAt.After(weekAgo) {
-			weekEntries = append(weekEntries, entry)
-		}
-	}
-
-	return weekEntries, nil
+	return m.entryService.LoadTodosBetween(now.AddDate(0, 0, -7), now)
 }
 
 // Load todos from the past month
 func (m Model) loadMonthTodos() ([]models.Entry, error) {
 	now := time.Now()
-	monthAgo := now.AddDate(0, -1, 0)
-
-	// For now, load today's entries and filter by date
-	// TODO: Extend storage service to support date range queries
-	allEntries, err := m.storage.LoadTodayEntries()
-	if err != nil {
-		return []models.Entry{}, err
-	}
-
-	var monthEntries []models.Entry
-	for _, entry := range allEntries {
-		if entry.Type == models.TypeTodo && entry.CreatedAt.After(monthAgo) {
-			monthEntries = append(monthEntries, entry)
-		}
-	}
-
-	return monthEntries, nil
+	return m.entryService.LoadTodosBetween(now.AddDate(0, -1, 0), now)
 }
 
-// Start editing a selected todo
-func (m Model) startEditingTodo() (tea.Model, tea.Cmd) {
+// Start editing the selected entry
+func (m Model) startEditing() (tea.Model, tea.Cmd) {
 	if m.selectedIdx < 0 || m.selectedIdx >= len(m.entries) {
 		return m, nil
 	}
 
 	entry := &m.entries[m.selectedIdx]
-	if entry.Type != models.TypeTodo {
-		return m, nil
-	}
-
-	m.editingTodoIdx = m.selectedIdx
+	m.editingIdx = m.selectedIdx
 	m.originalContent = entry.Content
 	m.textInput.SetValue(entry.Content)
+	m.textInput.CursorEnd()
 	m.textInput.Focus()
+	if m.currentMode == calendarMode {
+		// The input only receives keys while its pane is active
+		m.activePane = inputPane
+	}
 
 	return m, nil
 }
 
-// Save editing todo
-func (m Model) saveEditingTodo() (tea.Model, tea.Cmd) {
-	if m.editingTodoIdx < 0 || m.editingTodoIdx >= len(m.entries) {
+// Save the entry being edited
+func (m Model) saveEditing() (tea.Model, tea.Cmd) {
+	if m.editingIdx < 0 || m.editingIdx >= len(m.entries) {
 		return m, nil
 	}
 
 	newContent := strings.TrimSpace(m.textInput.Value())
-	if newContent == "" {
-		// Don't save empty content, cancel instead
-		return m.cancelEditingTodo()
+	if newContent == "" || newContent == m.originalContent {
+		// Nothing to save, cancel instead
+		return m.cancelEditing()
 	}
 
-	// Update the entry
-	entry := &m.entries[m.editingTodoIdx]
-	entry.Content = newContent
-	entry.UpdatedAt = time.Now()
+	// TODO mode forces entries to be todos, so edits there keep their type
+	recategorize := m.config.RecategorizeOnEdit && m.currentMode != todoMode
 
-	// Save to storage
-	if err := m.storage.SaveEntry(entry); err != nil {
-		// TODO: Show error message
-		return m.cancelEditingTodo()
+	entry := m.entries[m.editingIdx]
+	if _, err := m.entryService.EditEntry(entry.ID, newContent, recategorize); err != nil {
+		m.errorMessage = fmt.Sprintf("Save failed: %v", err)
+		m.errorTime = time.Now()
+		return m.cancelEditing()
 	}
 
-	// Exit edit mode
-	m.editingTodoIdx = -1
-	m.originalContent = ""
-	m.textInput.SetValue("")
-	m.textInput.Blur()
-
-	return m, m.loadFilteredEntries()
+	model, _ := m.cancelEditing()
+	return model, model.(Model).reloadEntries()
 }
 
-// Cancel editing todo
-func (m Model) cancelEditingTodo() (tea.Model, tea.Cmd) {
-	m.editingTodoIdx = -1
+// Cancel editing, returning focus to the entry list
+func (m Model) cancelEditing() (tea.Model, tea.Cmd) {
+	m.editingIdx = -1
 	m.originalContent = ""
 	m.textInput.SetValue("")
 	m.textInput.Blur()
+	if m.currentMode == calendarMode {
+		m.activePane = entriesPane
+	}
 	return m, nil
 }
 
+// moveSelectedDate moves the calendar selection and loads its entries,
+// reloading the whole month only when the selection crosses into another one
+func (m *Model) moveSelectedDate(direction string) tea.Cmd {
+	previous := m.selectedDate
+	m.selectedDate = m.getSpatialDate(m.selectedDate, direction)
+
+	if previous.Year() != m.selectedDate.Year() || previous.Month() != m.selectedDate.Month() {
+		return m.loadCalendarEntries()
+	}
+	return m.loadEntriesForDate(m.selectedDate)
+}
+
 // getSpatialDate calculates the date that would be in the specified direction
 // from the current date in the calendar grid layout
 func (m Model) getSpatialDate(currentDate time.Time, direction string) time.Time {
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"stak/internal/application"
+)
+
+// recategorizedMsg carries what /recategorize found or, once applied, changed
+type recategorizedMsg struct {
+	changes []application.Recategorization
+	applied bool
+	err     error
+}
+
+// recategorize re-runs the categorizer over every stored entry in the
+// background, saving the changes when apply is set
+func (m Model) recategorize(apply bool) tea.Cmd {
+	return func() tea.Msg {
+		changes, err := m.entryService.Recategorize(application.EntryFilter{}, apply)
+		return recategorizedMsg{changes: changes, applied: apply, err: err}
+	}
+}
+
+// previewRecategorize works out what re-running the categorizer would
+// change, to be shown until y applies it
+func (m Model) previewRecategorize() (tea.Model, tea.Cmd) {
+	return m, m.recategorize(false)
+}
+
+// handleRecategorizeKey applies the previewed changes on y and drops them
+// on any other key
+func (m Model) handleRecategorizeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.recategorizePreview = nil
+	if msg.String() != "y" && msg.String() != "Y" {
+		return m, nil
+	}
+	return m, m.recategorize(true)
+}
+
+// recategorized shows the previewed changes, or reports those applied
+func (m *Model) recategorized(msg recategorizedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.errorMessage = fmt.Sprintf("Recategorize failed: %v", msg.err)
+		m.errorTime = time.Now()
+		return nil
+	}
+	if msg.applied {
+		m.errorMessage = fmt.Sprintf("Recategorized %d entries", len(msg.changes))
+		m.errorTime = time.Now()
+		return m.reloadEntries()
+	}
+	if len(msg.changes) == 0 {
+		m.errorMessage = "Every entry already matches the categorization rules"
+		m.errorTime = time.Now()
+		return nil
+	}
+
+	m.recategorizePreview = msg.changes
+	return nil
+}
+
+// renderRecategorizeClean lists the previewed changes, one entry per line
+func (m Model) renderRecategorizeClean() string {
+	lines := []string{
+		fmt.Sprintf("Recategorizing would change %d entries:", len(m.recategorizePreview)),
+		"",
+	}
+	for _, change := range m.recategorizePreview {
+		lines = append(lines, fmt.Sprintf("%s  %s  %s",
+			change.Entry.CreatedAt.Format("2006-01-02"),
+			change,
+			firstLine(change.Entry.Content)))
+	}
+	lines = append(lines, "", "y to apply, any other key to cancel")
+	return strings.Join(lines, "\n")
+}
+
+// firstLine returns the first line of text, marking any that were cut
+func firstLine(text string) string {
+	line, _, more := strings.Cut(text, "\n")
+	if more {
+		return line + " …"
+	}
+	return line
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"sort"
+	"time"
+
+	"stak/internal/models"
+)
+
+// todoGroup buckets todos in TODO mode by how soon they need attention
+type todoGroup int
+
+const (
+	overdueGroup todoGroup = iota
+	dueTodayGroup
+	upcomingGroup
+	undatedGroup
+	doneGroup
+)
+
+func (g todoGroup) String() string {
+	switch g {
+	case overdueGroup:
+		return "Overdue"
+	case dueTodayGroup:
+		return "Due today"
+	case upcomingGroup:
+		return "Upcoming"
+	case undatedGroup:
+		return "No due date"
+	default:
+		return "Done"
+	}
+}
+
+func groupTodo(entry models.Entry, now time.Time) todoGroup {
+	switch {
+	case entry.TodoStatus == models.TodoCompleted || entry.TodoStatus == models.TodoCancelled:
+		return doneGroup
+	case entry.DueAt == nil:
+		return undatedGroup
+	case entry.IsOverdue(now):
+		return overdueGroup
+	}
+
+	y, m, d := entry.DueAt.Date()
+	ny, nm, nd := now.Date()
+	if y == ny && m == nm && d == nd {
+		return dueTodayGroup
+	}
+	return upcomingGroup
+}
+
+// sortTodos orders todos by group, then priority, then due date, oldest
+// capture first within ties
+func sortTodos(entries []models.Entry, now time.Time) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if ga, gb := groupTodo(a, now), groupTodo(b, now); ga != gb {
+			return ga < gb
+		}
+		if ra, rb := a.Priority.Rank(), b.Priority.Rank(); ra != rb {
+			return ra < rb
+		}
+		if da, db := a.DueDeadline(), b.DueDeadline(); !da.Equal(db) {
+			return da.Before(db)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+}
+
+// countOverdue counts the pending todos past their due date
+func countOverdue(entries []models.Entry, now time.Time) int {
+	count := 0
+	for _, entry := range entries {
+		if entry.IsOverdue(now) {
+			count++
+		}
+	}
+	return count
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -49,10 +50,14 @@ func (d todoItemDelegate) Render(w io.Writer, m list.Model, index int, listItem
 	var checkbox, content string
 
 	// Render checkbox
-	if entry.TodoStatus == models.TodoCompleted {
+	switch entry.TodoStatus {
+	case models.TodoCompleted:
 		checkbox = "✓"
 		content = todoCompletedStyle.Render(entry.Content)
-	} else {
+	case models.TodoCancelled:
+		checkbox = "✗"
+		content = todoCompletedStyle.Render(entry.Content)
+	default:
 		checkbox = "☐"
 		content = todoPendingStyle.Render(entry.Content)
 	}
@@ -133,9 +138,9 @@ func (m TodoListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if ok {
 				// Toggle todo status
 				if i.entry.TodoStatus == models.TodoCompleted {
-					i.entry.TodoStatus = models.TodoPending
+					i.entry.SetTodoStatus(models.TodoPending, time.Now())
 				} else {
-					i.entry.TodoStatus = models.TodoCompleted
+					i.entry.SetTodoStatus(models.TodoCompleted, time.Now())
 				}
 				
 				// Update the entry in our slice
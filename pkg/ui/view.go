@@ -2,6 +2,8 @@ package ui
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -41,6 +43,25 @@ var (
 			Foreground(lipgloss.Color("#AAAAAA")).
 			Padding(0, 1)
 
+	todoGroupStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FFA500"))
+
+	overdueStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF5F87"))
+
+	// Inline #tag, @person and +project tokens
+	inlineTokenStyles = map[string]lipgloss.Style{
+		"#": lipgloss.NewStyle().Foreground(lipgloss.Color("#5FAFFF")),
+		"@": lipgloss.NewStyle().Foreground(lipgloss.Color("#A550DF")),
+		"+": lipgloss.NewStyle().Foreground(lipgloss.Color("#5FD787")),
+	}
+
+	// Entities with a link, such as ticket IDs and email addresses
+	entityLinkStyle = lipgloss.NewStyle().
+			Underline(true).
+			Foreground(lipgloss.Color("#87D7FF"))
+
 	selectedEntryClean = lipgloss.NewStyle().
 				Background(lipgloss.Color("#444444")).
 				Foreground(lipgloss.Color("#FFFFFF"))
@@ -69,12 +90,22 @@ func (m Model) View() string {
 	if m.showHelp {
 		content := m.renderHelpClean(contentHeight)
 		sections = append(sections, m.addConsistentBorder(content, m.width, contentHeight, false))
+	} else if m.recategorizePreview != nil {
+		content := m.renderRecategorizeClean()
+		sections = append(sections, m.addConsistentBorder(content, m.width, contentHeight, true))
+	} else if m.linkReport != nil {
+		content := m.renderLinkReportClean()
+		sections = append(sections, m.addConsistentBorder(content, m.width, contentHeight, true))
+	} else if m.detail != nil {
+		content := m.renderDetailClean()
+		sections = append(sections, m.addConsistentBorder(content, m.width, contentHeight, true))
 	} else if m.currentMode == calendarMode {
 		sections = append(sections, m.renderCalendarView(contentHeight))
 	} else {
 		// For STAK and TODO modes, apply border to the main content area
 		content := m.renderEntriesClean(contentHeight)
-		isFocused := m.currentMode == todoMode && !m.textInput.Focused() // Focused when navigating todos
+		// Focused when navigating the entry list instead of typing
+		isFocused := !m.textInput.Focused()
 		sections = append(sections, m.addConsistentBorder(content, m.width, contentHeight, isFocused))
 	}
 
@@ -95,33 +126,51 @@ func (m Model) renderIRCStatusBar() string {
 	var statusKey string
 	switch m.currentMode {
 	case todoMode:
-		if m.editingTodoIdx >= 0 {
-			statusKey = "EDITING"
-		} else {
-			statusKey = "TODO"
-		}
+		statusKey = "TODO"
 	case stakMode:
 		statusKey = "STAK"
 	case calendarMode:
 		statusKey = "CALENDAR"
+	case searchMode:
+		statusKey = "SEARCH"
+	case trashMode:
+		statusKey = "TRASH"
 	default:
 		statusKey = "STAK"
 	}
+	if m.editingIdx >= 0 {
+		statusKey = "EDITING"
+	}
+	if m.confirmDeleteID != "" {
+		statusKey = "DELETE"
+	}
+	if m.duplicate != nil {
+		statusKey = "DUPLICATE"
+	}
 
 	// Context information
 	var contextText string
 	switch m.currentMode {
 	case todoMode:
-		if m.editingTodoIdx < 0 {
-			completed := 0
-			for _, entry := range m.entries {
-				if entry.Type == models.TypeTodo && entry.TodoStatus == models.TodoCompleted {
-					completed++
-				}
+		counts := make(map[models.TodoStatus]int)
+		for _, entry := range m.entries {
+			if entry.Type == models.TypeTodo {
+				counts[entry.TodoStatus]++
 			}
-			contextText = fmt.Sprintf("%d/%d completed", completed, len(m.entries))
-		} else {
-			contextText = "Editing todo item"
+		}
+		contextText = fmt.Sprintf("%d pending • %d completed • %d cancelled",
+			counts[models.TodoPending], counts[models.TodoCompleted], counts[models.TodoCancelled])
+		if m.todoStatus != "" {
+			contextText = fmt.Sprintf("%d %s", counts[m.todoStatus], m.todoStatus)
+		}
+		if overdue := countOverdue(m.entries, time.Now()); overdue > 0 {
+			contextText += fmt.Sprintf(" • %d overdue", overdue)
+		}
+		switch m.todoScope {
+		case weekTodos:
+			contextText += " • past week"
+		case monthTodos:
+			contextText += " • past month"
 		}
 	case stakMode:
 		today := time.Now().Format("2006-01-02")
@@ -137,9 +186,26 @@ func (m Model) renderIRCStatusBar() string {
 			paneText = "CALENDAR"
 		}
 		contextText = fmt.Sprintf("%s • %s", m.selectedDate.Format("January 2006"), paneText)
+	case searchMode:
+		scope := "all"
+		if m.searchLinksOnly {
+			scope = "links"
+		}
+		contextText = fmt.Sprintf("\"%s\" in %s • %d results", m.searchQuery, scope, len(m.entries))
+	case trashMode:
+		contextText = fmt.Sprintf("%d deleted or archived entries • r to restore", len(m.entries))
 	default:
 		contextText = fmt.Sprintf("%d entries", len(m.entries))
 	}
+	if m.editingIdx >= 0 {
+		contextText = "Editing entry • enter to save, esc to cancel"
+	}
+	if m.confirmDeleteID != "" {
+		contextText = fmt.Sprintf("Delete \"%s\"? (y/n)", m.confirmDeleteContent)
+	}
+	if m.duplicate != nil {
+		contextText = m.duplicatePrompt()
+	}
 
 	// Time or error
 	var timeText string
@@ -177,6 +243,10 @@ func (m Model) renderEntriesClean(height int) string {
 			emptyText = "No todos yet. Start typing to add one."
 		case stakMode:
 			emptyText = "No entries yet. Start typing to add one."
+		case searchMode:
+			emptyText = fmt.Sprintf("No results for \"%s\". Type a new query or press Esc to go back.", m.searchQuery)
+		case trashMode:
+			emptyText = "Trash is empty."
 		default:
 			emptyText = "No entries found."
 		}
@@ -189,8 +259,16 @@ func (m Model) renderEntriesClean(height int) string {
 	var renderedEntries []string
 
 	// Render all entries - let the border function handle height constraints
+	now := time.Now()
 	for i := 0; i < len(m.entries); i++ {
 		entry := m.entries[i]
+		if m.currentMode == todoMode {
+			// Todos are sorted by group, so a header starts each new group
+			group := groupTodo(entry, now)
+			if i == 0 || groupTodo(m.entries[i-1], now) != group {
+				renderedEntries = append(renderedEntries, todoGroupStyle.Render(group.String()))
+			}
+		}
 		selected := (i == m.selectedIdx)
 		content := m.renderEntryClean(entry, selected)
 		renderedEntries = append(renderedEntries, content)
@@ -202,25 +280,58 @@ func (m Model) renderEntriesClean(height int) string {
 	return entriesText
 }
 
+// todoMark is the checkbox shown before a todo with status
+func todoMark(status models.TodoStatus) string {
+	switch status {
+	case models.TodoCompleted:
+		return "✓ "
+	case models.TodoCancelled:
+		return "✗ "
+	}
+	return "□ "
+}
+
 func (m Model) renderEntryClean(entry models.Entry, selected bool) string {
 	timestamp := entry.CreatedAt.Format("15:04")
+	if m.currentMode == searchMode || m.currentMode == trashMode {
+		// Search results and the trash span many days, so include the date
+		timestamp = entry.CreatedAt.Format("2006-01-02 15:04")
+	}
+	if m.currentMode == trashMode {
+		if _, archived := entry.Metadata["archived_at"]; archived {
+			timestamp += " [archived]"
+		} else {
+			timestamp += " [deleted]"
+		}
+	}
 
 	var content string
 	switch entry.Type {
 	case models.TypeTodo:
-		if entry.TodoStatus == models.TodoCompleted {
-			content = "✓ " + entry.Content
-		} else {
-			content = "□ " + entry.Content
+		content = todoMark(entry.TodoStatus) + entry.Content
+		if entry.Priority != "" {
+			content += " !" + string(entry.Priority)
+		}
+		if due := entry.FormatDue(); due != "" {
+			due = "due " + due
+			if entry.IsOverdue(time.Now()) && !selected {
+				due = overdueStyle.Render(due)
+			}
+			content += " " + due
 		}
 	default:
 		content = entry.Content
 	}
+	if !selected {
+		// The selection background already sets the line apart
+		content = linkEntities(content, entry.Entities)
+		content = highlightInlineTokens(content)
+	}
 
 	line := fmt.Sprintf("%s %s", timestamp, content)
 
 	if selected {
-		if m.currentMode == todoMode && !m.textInput.Focused() {
+		if !m.textInput.Focused() {
 			// Add visual indicator for navigation mode
 			line = "› " + line
 		}
@@ -230,6 +341,43 @@ func (m Model) renderEntryClean(entry models.Entry, selected bool) string {
 	return line
 }
 
+// highlightInlineTokens colours the #tag, @person and +project tokens in text
+func highlightInlineTokens(text string) string {
+	return models.InlineTokenRegex.ReplaceAllStringFunc(text, func(token string) string {
+		trimmed := strings.TrimLeft(token, " \t\n")
+		lead := token[:len(token)-len(trimmed)]
+		return lead + inlineTokenStyles[trimmed[:1]].Render(trimmed)
+	})
+}
+
+// linkEntities underlines the entities in text that have a link and makes
+// them clickable in terminals that support OSC 8 hyperlinks
+func linkEntities(text string, entities []models.Entity) string {
+	links := make(map[string]string)
+	var values []string
+	for _, entity := range entities {
+		if entity.URL != "" && links[entity.Value] == "" {
+			links[entity.Value] = entity.URL
+			values = append(values, regexp.QuoteMeta(entity.Value))
+		}
+	}
+	if len(values) == 0 {
+		return text
+	}
+
+	// Longest first, so a value is never linked inside a longer one
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	re := regexp.MustCompile(strings.Join(values, "|"))
+	return re.ReplaceAllStringFunc(text, func(value string) string {
+		return hyperlink(links[value], entityLinkStyle.Render(value))
+	})
+}
+
+// hyperlink wraps text in an OSC 8 terminal hyperlink to url
+func hyperlink(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
 func (m Model) renderHelpClean(height int) string {
 	help := strings.Join(m.commands, "\n")
 	// Don't apply sizing here - let the border function handle it
@@ -551,11 +699,7 @@ func (m Model) renderSelectedDateEntries(width, height int) string {
 
 		switch entry.Type {
 		case models.TypeTodo:
-			if entry.TodoStatus == models.TodoCompleted {
-				content = "✓ " + entry.Content
-			} else {
-				content = "□ " + entry.Content
-			}
+			content = todoMark(entry.TodoStatus) + entry.Content
 		default:
 			content = entry.Content
 		}